@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/ai"
+	"github.com/baxromumarov/job-hunter/internal/core"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// rescoreReportEvery is how many jobs pass between progress log lines.
+const rescoreReportEvery = 25
+
+// runRescore implements `server rescore [flags]` and returns the exit code.
+func runRescore(args []string) int {
+	fs := flag.NewFlagSet("rescore", flag.ContinueOnError)
+	profile := fs.String("profile", "", "rescore only this profile (default: all active profiles)")
+	jobIDs := fs.String("jobs", "", "comma-separated job IDs")
+	sourceID := fs.Int("source", 0, "rescore only jobs of this source ID")
+	since := fs.String("since", "", "rescore only jobs stored since a date (2006-01-02) or for a duration (e.g. 72h)")
+	staleOnly := fs.Bool("stale", false, "only rescore scores from an older profile version, rule set or AI model")
+	limit := fs.Int("limit", 0, "maximum number of jobs to visit (0: no limit)")
+	aiPerMinute := fs.Int("ai-per-minute", 0, "AI calls per minute (default: RESCORE_AI_PER_MINUTE or 30)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	req := core.RescoreRequest{
+		Profile:     *profile,
+		SourceID:    *sourceID,
+		StaleOnly:   *staleOnly,
+		Limit:       max(*limit, 0),
+		AIPerMinute: max(*aiPerMinute, 0),
+	}
+	var err error
+	if req.JobIDs, err = parseIDs(*jobIDs); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid -jobs:", err)
+		return 2
+	}
+	if req.Since, err = parseSince(*since); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid -since:", err)
+		return 2
+	}
+
+	dbStore := openStore()
+	defer dbStore.Close()
+
+	ingestion := core.NewIngestionService(dbStore, core.NewMatcherService(ai.NewClient(dbStore), dbStore))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	run, progress, err := ingestion.RunRescore(ctx, req, func(p core.RescoreProgress) {
+		if p.Jobs%rescoreReportEvery == 0 {
+			slog.Info("rescore progress", "jobs", p.Jobs, "rescored", p.Rescored, "skipped", p.Skipped, "ai_calls", p.AICalls, "ai_failed", p.AIFailed)
+		}
+	})
+	switch {
+	case errors.Is(err, core.ErrRescoreActive):
+		slog.Error("rescore already running", "run_id", run.ID)
+		return 1
+	case errors.Is(err, store.ErrNotFound):
+		slog.Error("profile is not active or does not exist", "profile", req.Profile)
+		return 1
+	case err != nil:
+		slog.Error("rescore failed", "error", err)
+		return 1
+	}
+	slog.Info("rescore complete", "run_id", run.ID, "jobs", progress.Jobs, "rescored", progress.Rescored, "skipped", progress.Skipped, "ai_calls", progress.AICalls, "ai_failed", progress.AIFailed)
+	return 0
+}
+
+func parseIDs(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseSince accepts a date or a duration back from now.
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
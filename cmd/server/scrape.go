@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/ai"
+	"github.com/baxromumarov/job-hunter/internal/core"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// runScrape implements `server scrape [flags] <url>`: a dry run that scrapes
+// and scores one URL and prints the result without writing to the database.
+func runScrape(args []string) int {
+	// Logs go to stderr so -json output stays parseable.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	fs := flag.NewFlagSet("scrape", flag.ContinueOnError)
+	kind := fs.String("scraper", "", "force a scraper: "+strings.Join(core.ScraperKinds, ", ")+" (default: picked by host)")
+	sourceType := fs.String("type", "", "source type seen by filter rules: job_board or company_page (default: by scraper)")
+	relaxed := fs.Bool("relaxed", false, "use the relaxed extraction right away")
+	since := fs.String("since", "", "ask scrapers for postings since a date (2006-01-02) or a duration (e.g. 72h) (default: the ingestion window)")
+	profile := fs.String("profile", "", "score only this profile (default: all active profiles)")
+	noAI := fs.Bool("no-ai", false, "score with keywords only")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: server scrape [flags] <url>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *kind != "" && !slices.Contains(core.ScraperKinds, *kind) {
+		fmt.Fprintf(os.Stderr, "invalid -scraper %q: want one of %s\n", *kind, strings.Join(core.ScraperKinds, ", "))
+		return 2
+	}
+
+	req := core.DryRunRequest{
+		URL:        fs.Arg(0),
+		Scraper:    *kind,
+		SourceType: *sourceType,
+		Relaxed:    *relaxed,
+		Profile:    *profile,
+		SkipAI:     *noAI,
+	}
+	var err error
+	if req.Since, err = parseSince(*since); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid -since:", err)
+		return 2
+	}
+
+	dbStore := connectStore()
+	defer dbStore.Close()
+
+	// No AI cache: filling it would write to the database.
+	ingestion := core.NewIngestionService(dbStore, core.NewMatcherService(ai.NewClient(nil), dbStore))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	res, err := ingestion.DryRun(ctx, req)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		slog.Error("profile is not active or does not exist", "profile", req.Profile)
+		return 1
+	case err != nil && res == nil:
+		slog.Error("dry run failed", "error", err)
+		return 1
+	case err != nil:
+		// Print what was gathered before the scrape failed or was cancelled.
+		slog.Error("dry run failed", "error", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(res); encErr != nil {
+			slog.Error("write output failed", "error", encErr)
+			return 1
+		}
+	} else {
+		printDryRun(res)
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+func printDryRun(res *core.DryRunResult) {
+	passed := 0
+	for _, job := range res.Jobs {
+		if job.Passed {
+			passed++
+		}
+	}
+	mode := "strict"
+	if res.Relaxed {
+		mode = "relaxed"
+	}
+	fmt.Printf("%s: scraper %s (%s), source type %s, since %s, rules %s\n",
+		res.URL, res.Scraper, mode, res.SourceType, res.Since.Format(time.DateOnly), res.RulesVersion)
+	fmt.Printf("%d jobs extracted, %d would be listed\n\n", len(res.Jobs), passed)
+	if len(res.Jobs) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tSCORE\tTITLE\tCOMPANY\tLOCATION\tREASON")
+	for _, job := range res.Jobs {
+		result := "drop"
+		if job.Passed {
+			result = "pass"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			result, bestScore(job), truncate(job.Title, 50), truncate(job.Company, 24), truncate(job.Location, 24), job.Reason)
+	}
+	tw.Flush()
+}
+
+// bestScore is the highest score any profile gave the job, or "-" when the
+// job was dropped before profile scoring.
+func bestScore(job core.DryRunJob) string {
+	best := -1
+	for _, p := range job.Profiles {
+		best = max(best, p.Score)
+	}
+	if best < 0 {
+		return "-"
+	}
+	return strconv.Itoa(best)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n-1]) + "…"
+}
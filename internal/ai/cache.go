@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+)
+
+// Operations, as recorded in the cache and its hit/miss counters.
+const (
+	OpClassify = "classify"
+	OpMatch    = "match"
+	OpExtract  = "extract"
+)
+
+// ClassifyPromptVersion identifies the ClassifyWebsite prompt. Bump it
+// whenever the prompt changes so cached classifications are not reused.
+const ClassifyPromptVersion = "classify-v1"
+
+// defaultCacheTTL is how long answers are reused without AI_CACHE_TTL_HOURS.
+const defaultCacheTTL = 7 * 24 * time.Hour
+
+// Cache stores AI answers by operation, model, prompt version and a hash of
+// the input. store.Store implements it.
+type Cache interface {
+	GetAIResponse(ctx context.Context, operation, model, promptVersion, inputHash string) ([]byte, bool, error)
+	PutAIResponse(ctx context.Context, operation, model, promptVersion, inputHash string, response []byte, ttl time.Duration) error
+}
+
+// CachedClient reuses earlier answers of the wrapped client. The match key
+// hashes the job together with the candidate profile, so editing a profile
+// invalidates its cached scores. Cache failures fall through to the client.
+type CachedClient struct {
+	inner Client
+	cache Cache
+	ttl   time.Duration
+}
+
+func NewCachedClient(inner Client, cache Cache, ttl time.Duration) *CachedClient {
+	return &CachedClient{inner: inner, cache: cache, ttl: ttl}
+}
+
+// cacheTTLFromEnv reads AI_CACHE_TTL_HOURS; 0 turns the cache off.
+func cacheTTLFromEnv() time.Duration {
+	v := strings.TrimSpace(os.Getenv("AI_CACHE_TTL_HOURS"))
+	if v == "" {
+		return defaultCacheTTL
+	}
+	hours, err := strconv.ParseFloat(v, 64)
+	if err != nil || hours < 0 {
+		return defaultCacheTTL
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// withCache wraps a real provider in the cache. The mock answers randomly
+// and for free, so it is never cached.
+func withCache(client Client, cache Cache) Client {
+	if cache == nil {
+		return client
+	}
+	if _, ok := client.(*MockClient); ok {
+		return client
+	}
+	ttl := cacheTTLFromEnv()
+	if ttl <= 0 {
+		return client
+	}
+	return NewCachedClient(client, cache, ttl)
+}
+
+func (c *CachedClient) Model() string {
+	return c.inner.Model()
+}
+
+func (c *CachedClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error) {
+	result, err := cachedCall(ctx, c, OpClassify, ClassifyPromptVersion, data, func() (WebsiteClassification, error) {
+		return c.inner.ClassifyWebsite(ctx, data)
+	})
+	result.Model = c.inner.Model()
+	return result, err
+}
+
+func (c *CachedClient) MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error) {
+	result, err := cachedCall(ctx, c, OpMatch, MatchPromptVersion, matchInput(job, profile), func() (JobMatch, error) {
+		return c.inner.MatchJob(ctx, job, profile)
+	})
+	result.Model, result.PromptVersion = c.inner.Model(), MatchPromptVersion
+	return result, err
+}
+
+func (c *CachedClient) ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error) {
+	result, err := cachedCall(ctx, c, OpExtract, ExtractPromptVersion, job, func() (JobFacts, error) {
+		return c.inner.ExtractJobFacts(ctx, job)
+	})
+	result.Model = c.inner.Model()
+	return result, err
+}
+
+// MatchJobs answers cached jobs itself and sends only the rest to the wrapped
+// client. Batch answers are cached per job, apart from single answers.
+func (c *CachedClient) MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error) {
+	result := newJobMatches(len(jobs))
+	hashes := make([]string, len(jobs))
+	var (
+		missed     []int
+		missedJobs []JobData
+	)
+	for i, job := range jobs {
+		hashes[i] = inputHash(matchInput(job, profile))
+		match, ok := cacheGet[JobMatch](ctx, c, OpMatch, MatchBatchPromptVersion, hashes[i])
+		if !ok {
+			missed = append(missed, i)
+			missedJobs = append(missedJobs, job)
+			continue
+		}
+		match.Model, match.PromptVersion = c.inner.Model(), MatchBatchPromptVersion
+		result.Matches[i] = match
+	}
+	if len(missed) == 0 {
+		return result, nil
+	}
+
+	answer, err := c.inner.MatchJobs(ctx, missedJobs, profile)
+	result.Usage = answer.Usage
+	if err != nil {
+		return result, err
+	}
+	for j, i := range missed {
+		result.Matches[i], result.Errs[i] = answer.Matches[j], answer.Errs[j]
+		if answer.Errs[j] == nil {
+			cachePut(ctx, c, OpMatch, MatchBatchPromptVersion, hashes[i], answer.Matches[j])
+		}
+	}
+	return result, nil
+}
+
+// ExtractJobsFacts answers cached jobs itself and sends only the rest to the
+// wrapped client. Batch answers are cached per job, apart from single answers.
+func (c *CachedClient) ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error) {
+	result := newJobFactsBatch(len(jobs))
+	hashes := make([]string, len(jobs))
+	var (
+		missed     []int
+		missedJobs []JobData
+	)
+	for i, job := range jobs {
+		hashes[i] = inputHash(job)
+		facts, ok := cacheGet[JobFacts](ctx, c, OpExtract, ExtractBatchPromptVersion, hashes[i])
+		if !ok {
+			missed = append(missed, i)
+			missedJobs = append(missedJobs, job)
+			continue
+		}
+		facts.Model = c.inner.Model()
+		result.Facts[i] = facts
+	}
+	if len(missed) == 0 {
+		return result, nil
+	}
+
+	answer, err := c.inner.ExtractJobsFacts(ctx, missedJobs)
+	result.Usage = answer.Usage
+	if err != nil {
+		return result, err
+	}
+	for j, i := range missed {
+		result.Facts[i], result.Errs[i] = answer.Facts[j], answer.Errs[j]
+		if answer.Errs[j] == nil {
+			cachePut(ctx, c, OpExtract, ExtractBatchPromptVersion, hashes[i], answer.Facts[j])
+		}
+	}
+	return result, nil
+}
+
+// matchInput is what a match answer depends on besides model and prompt.
+func matchInput(job JobData, profile CandidateProfile) any {
+	return struct {
+		Job     JobData
+		Profile CandidateProfile
+	}{job, profile}
+}
+
+func cachedCall[T any](ctx context.Context, c *CachedClient, op, promptVersion string, input any, call func() (T, error)) (T, error) {
+	hash := inputHash(input)
+	if result, ok := cacheGet[T](ctx, c, op, promptVersion, hash); ok {
+		return result, nil
+	}
+	result, err := call()
+	if err != nil {
+		return result, err
+	}
+	cachePut(ctx, c, op, promptVersion, hash, result)
+	return result, nil
+}
+
+// cacheGet looks up an answer and counts the hit or miss.
+func cacheGet[T any](ctx context.Context, c *CachedClient, op, promptVersion, hash string) (T, bool) {
+	var result T
+	model := c.inner.Model()
+	cached, ok, err := c.cache.GetAIResponse(ctx, op, model, promptVersion, hash)
+	if err != nil && ctx.Err() == nil {
+		observability.IncError(observability.ErrorStore, "ai_cache")
+		slog.Warn("ai cache read failed", "operation", op, "model", model, "error", err)
+	}
+	if ok && json.Unmarshal(cached, &result) == nil {
+		observability.IncAICache(op, "hit")
+		return result, true
+	}
+	observability.IncAICache(op, "miss")
+	return result, false
+}
+
+func cachePut(ctx context.Context, c *CachedClient, op, promptVersion, hash string, result any) {
+	model := c.inner.Model()
+	response, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := c.cache.PutAIResponse(ctx, op, model, promptVersion, hash, response, c.ttl); err != nil && ctx.Err() == nil {
+		observability.IncError(observability.ErrorStore, "ai_cache")
+		slog.Warn("ai cache write failed", "operation", op, "model", model, "error", err)
+	}
+}
+
+// inputHash is the hex SHA-256 of the input's JSON encoding.
+func inputHash(input any) string {
+	data, _ := json.Marshal(input)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"math/rand"
@@ -12,37 +13,171 @@ import (
 type Client interface {
 	ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error)
 	MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error)
+	// MatchJobs scores several jobs against one profile in a single request.
+	// An error fails the whole batch; a job the model did not answer properly
+	// only fails its own entry.
+	MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error)
+	// ExtractJobFacts reads salary, visa sponsorship, remote regions, required
+	// experience, tech stack and employment type from a posting.
+	ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error)
+	// ExtractJobsFacts reads the facts of several postings in a single
+	// request. Errors work like in MatchJobs.
+	ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error)
+	// Model names the provider and model behind the client, e.g.
+	// "gemini/gemini-2.0-flash". For a fallback chain it is the preferred
+	// one; results carry the model that actually answered.
+	Model() string
 }
 
-// NewClient creates an AI client based on the AI_PROVIDER environment variable.
-// Supported providers: "gemini" (default if GEMINI_API_KEY is set), "mock"
+// NewClient creates an AI client from the environment. AI_PROVIDERS and the
+// per-operation AI_*_PROVIDERS variables configure fallback chains;
+// without them a single provider is picked by AI_PROVIDER. Unless st is nil,
+// real providers record their usage in it and their answers are cached there
+// for AI_CACHE_TTL_HOURS.
+// Supported providers: "gemini" (default if GEMINI_API_KEY is set), "openai"
+// (default if OPENAI_API_KEY or OPENAI_BASE_URL is set), "mock"
 //
 // Environment variables:
-//   - AI_PROVIDER: "gemini" or "mock" (optional, auto-detected)
+//   - AI_PROVIDER: "gemini", "openai" or "mock" (optional, auto-detected)
+//   - AI_PROVIDERS: fallback chain for every operation, e.g. "gemini,openai:llama3.1"; "mock" is not allowed in a chain
+//   - AI_CLASSIFY_PROVIDERS, AI_MATCH_PROVIDERS, AI_EXTRACT_PROVIDERS: chain for one operation, overriding AI_PROVIDERS
 //   - GEMINI_API_KEY: Your Google Gemini API key (get free at https://aistudio.google.com/apikey)
-func NewClient() Client {
+//   - GEMINI_MODEL: model name (default gemini-1.5-flash)
+//   - OPENAI_BASE_URL: chat completions base URL (default https://api.openai.com/v1)
+//   - OPENAI_API_KEY: bearer token (optional for local servers)
+//   - OPENAI_MODEL: model name (default gpt-4o-mini)
+//   - OPENAI_JSON_MODE: "false" for servers that reject response_format
+//   - AI_CACHE_TTL_HOURS: how long answers are reused (default 168, 0 disables the cache)
+//   - AI_PRICES: "model=input:output,..." USD per million tokens for cost estimates
+func NewClient(st Store) Client {
+	b := clientBuilder{prices: pricesFromEnv(), health: newProviderHealth()}
+	if st != nil {
+		b.cache, b.usage = st, st
+	}
+
+	all := os.Getenv("AI_PROVIDERS")
+	classifySpec := cmp.Or(os.Getenv("AI_CLASSIFY_PROVIDERS"), all)
+	matchSpec := cmp.Or(os.Getenv("AI_MATCH_PROVIDERS"), all)
+	extractSpec := cmp.Or(os.Getenv("AI_EXTRACT_PROVIDERS"), all)
+	if classifySpec == "" && matchSpec == "" && extractSpec == "" {
+		return b.defaultClient()
+	}
+	if classifySpec == matchSpec && matchSpec == extractSpec {
+		return b.chain("all operations", classifySpec)
+	}
+	return NewRoutedClient(
+		b.chain("classification", classifySpec),
+		b.chain("matching", matchSpec),
+		b.chain("extraction", extractSpec),
+	)
+}
+
+// clientBuilder wraps every real provider in metering and then the cache,
+// so cache hits cost nothing. Its chains share one provider health.
+type clientBuilder struct {
+	cache  Cache
+	usage  UsageRecorder
+	prices map[string]Price
+	health *providerHealth
+}
+
+func (b clientBuilder) wrap(client Client) Client {
+	return withCache(withUsage(client, b.usage, b.prices), b.cache)
+}
+
+// chain builds a fallback chain from a comma-separated list of
+// provider[:model] entries. An empty list uses the single default provider.
+// The mock is refused: a quota hit would silently turn real scores into mock
+// ones.
+func (b clientBuilder) chain(operation, spec string) Client {
+	if spec == "" {
+		return b.defaultClient()
+	}
+	var (
+		providers []Client
+		names     []string
+	)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, model, _ := strings.Cut(entry, ":")
+		name = strings.ToLower(name)
+		if name == MockModel {
+			fmt.Printf("WARNING: skipping AI provider %q: the mock cannot be part of a fallback chain, use AI_PROVIDER=mock\n", entry)
+			continue
+		}
+		client, err := newProvider(name, model)
+		if err != nil {
+			fmt.Printf("WARNING: skipping AI provider %q: %v\n", entry, err)
+			continue
+		}
+		providers = append(providers, b.wrap(client))
+		names = append(names, client.Model())
+	}
+	if len(providers) == 0 {
+		fmt.Printf("WARNING: no usable AI provider in %q, falling back to mock\n", spec)
+		providers = []Client{NewMockClient()}
+		names = []string{MockModel}
+	}
+	fmt.Printf("Using AI providers for %s: %s\n", operation, strings.Join(names, " -> "))
+	return &FallbackClient{providers: providers, health: b.health}
+}
+
+// newProvider creates one provider, overriding its default model when model
+// is set.
+func newProvider(name, model string) (Client, error) {
+	switch name {
+	case "gemini":
+		key := os.Getenv("GEMINI_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY not set")
+		}
+		return NewGeminiClient(key).WithModel(cmp.Or(model, os.Getenv("GEMINI_MODEL"), defaultModel)), nil
+	case "openai":
+		key, base := os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_BASE_URL")
+		if key == "" && base == "" {
+			return nil, fmt.Errorf("neither OPENAI_API_KEY nor OPENAI_BASE_URL set")
+		}
+		return NewOpenAIClient(base, key, cmp.Or(model, os.Getenv("OPENAI_MODEL"))).
+			WithJSONMode(!strings.EqualFold(os.Getenv("OPENAI_JSON_MODE"), "false")), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+}
+
+// defaultClient picks a single provider by AI_PROVIDER or, without it,
+// by which credentials are set.
+func (b clientBuilder) defaultClient() Client {
 	provider := strings.ToLower(os.Getenv("AI_PROVIDER"))
 	geminiKey := os.Getenv("GEMINI_API_KEY")
+	openAIKey := os.Getenv("OPENAI_API_KEY")
+	openAIBase := os.Getenv("OPENAI_BASE_URL")
 
 	// Auto-detect provider if not specified
 	if provider == "" {
-		if geminiKey != "" {
+		switch {
+		case geminiKey != "":
 			provider = "gemini"
-		} else {
+		case openAIKey != "" || openAIBase != "":
+			provider = "openai"
+		default:
 			provider = "mock"
 		}
 	}
 
 	switch provider {
-	case "gemini":
-		if geminiKey == "" {
-			fmt.Println("WARNING: AI_PROVIDER=gemini but GEMINI_API_KEY not set, falling back to mock")
+	case "gemini", "openai":
+		client, err := newProvider(provider, "")
+		if err != nil {
+			fmt.Printf("WARNING: AI_PROVIDER=%s but %v, falling back to mock\n", provider, err)
 			return NewMockClient()
 		}
-		fmt.Println("Using Gemini AI client (free tier: 1,500 requests/day)")
-		return NewGeminiClient(geminiKey)
+		fmt.Printf("Using AI client %s\n", client.Model())
+		return b.wrap(client)
 	default:
-		fmt.Println("Using Mock AI client (set GEMINI_API_KEY for real AI)")
+		fmt.Println("Using Mock AI client (set GEMINI_API_KEY or OPENAI_BASE_URL for real AI)")
 		return NewMockClient()
 	}
 }
@@ -59,6 +194,9 @@ type WebsiteClassification struct {
 	TechRelated bool    `json:"tech_related"`
 	Confidence  float64 `json:"confidence"`
 	Reason      string  `json:"reason"`
+	// Model names the provider and model that answered.
+	Model string `json:"-"`
+	Usage Usage  `json:"-"`
 }
 
 type JobData struct {
@@ -67,22 +205,67 @@ type JobData struct {
 }
 
 type CandidateProfile struct {
-	TechStack []string
+	TechStack   []string
+	Keywords    []string
+	Seniority   []string
+	Locations   []string
+	RemoteOnly  bool
+	SalaryFloor int
+	Preferences string
 }
 
+// MatchPromptVersion identifies the MatchJob prompt. Bump it whenever the
+// prompt changes so stored scores show which prompt produced them.
+const MatchPromptVersion = "match-v1"
+
+// MatchBatchPromptVersion identifies the MatchJobs prompt, which packs
+// several jobs into one request. Bump it whenever that prompt changes.
+const MatchBatchPromptVersion = "match-batch-v2"
+
 type JobMatch struct {
 	MatchScore   int      `json:"match_score"`
 	Strengths    []string `json:"strengths"`
 	Weaknesses   []string `json:"weaknesses"`
 	ShortSummary string   `json:"short_summary"`
+	// Model names the provider and model that answered, PromptVersion the
+	// prompt it answered.
+	Model         string `json:"-"`
+	PromptVersion string `json:"-"`
+	Usage         Usage  `json:"-"`
+}
+
+// JobMatches answers a MatchJobs batch. Matches and Errs are indexed like the
+// jobs; a job with an Err can be retried on its own with MatchJob.
+type JobMatches struct {
+	Matches []JobMatch
+	Errs    []error
+	// Usage is the tokens of the whole request.
+	Usage Usage
+}
+
+func newJobMatches(n int) JobMatches {
+	return JobMatches{Matches: make([]JobMatch, n), Errs: make([]error, n)}
 }
 
+// Usage is the tokens one request consumed, as reported by the provider.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// MockModel is the model name of the MockClient.
+const MockModel = "mock"
+
 type MockClient struct{}
 
 func NewMockClient() *MockClient {
 	return &MockClient{}
 }
 
+func (m *MockClient) Model() string {
+	return MockModel
+}
+
 func (m *MockClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error) {
 	// Simulate AI delay
 	time.Sleep(500 * time.Millisecond)
@@ -96,16 +279,46 @@ func (m *MockClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (Web
 		TechRelated: isTech,
 		Confidence:  0.8 + (rand.Float64() * 0.2),
 		Reason:      "Mock AI analysis determined this is likely a job site.",
+		Model:       m.Model(),
 	}, nil
 }
 
 func (m *MockClient) MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error) {
 	time.Sleep(500 * time.Millisecond)
+	return m.match(MatchPromptVersion), nil
+}
+
+func (m *MockClient) MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error) {
+	time.Sleep(500 * time.Millisecond)
+	result := newJobMatches(len(jobs))
+	for i := range jobs {
+		result.Matches[i] = m.match(MatchBatchPromptVersion)
+	}
+	return result, nil
+}
+
+// ExtractJobFacts answers with the deterministic heuristic extractor.
+func (m *MockClient) ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error) {
+	return HeuristicFacts(job), nil
+}
+
+// ExtractJobsFacts answers every job with the heuristic extractor.
+func (m *MockClient) ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error) {
+	result := newJobFactsBatch(len(jobs))
+	for i, job := range jobs {
+		result.Facts[i] = HeuristicFacts(job)
+	}
+	return result, nil
+}
+
+func (m *MockClient) match(promptVersion string) JobMatch {
 	score := 70 + rand.Intn(30)
 	return JobMatch{
-		MatchScore:   score,
-		Strengths:    []string{"Golang", "Backend"},
-		Weaknesses:   []string{"Unknown stack"},
-		ShortSummary: fmt.Sprintf("Mock match score: %d", score),
-	}, nil
+		MatchScore:    score,
+		Strengths:     []string{"Golang", "Backend"},
+		Weaknesses:    []string{"Unknown stack"},
+		ShortSummary:  fmt.Sprintf("Mock match score: %d", score),
+		Model:         m.Model(),
+		PromptVersion: promptVersion,
+	}
 }
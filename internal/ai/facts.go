@@ -0,0 +1,498 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ExtractPromptVersion identifies the ExtractJobFacts prompt. Bump it
+// whenever the prompt changes so cached extractions are not reused.
+const ExtractPromptVersion = "extract-v1"
+
+// ExtractBatchPromptVersion identifies the ExtractJobsFacts prompt.
+const ExtractBatchPromptVersion = "extract-batch-v1"
+
+// HeuristicModel names the rule-based extractor in JobFacts.Model.
+const HeuristicModel = "heuristic"
+
+// Employment types, as normalized in JobFacts.
+const (
+	EmploymentFullTime   = "full_time"
+	EmploymentPartTime   = "part_time"
+	EmploymentContract   = "contract"
+	EmploymentInternship = "internship"
+	EmploymentTemporary  = "temporary"
+)
+
+// Salary periods, as normalized in JobFacts.
+const (
+	PeriodYear  = "year"
+	PeriodMonth = "month"
+	PeriodHour  = "hour"
+)
+
+// Fact is one extracted detail. Confidence runs from 0 to 1; 0 means the
+// description did not say and Value is empty.
+type Fact[T any] struct {
+	Value      T       `json:"value"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Known reports whether the fact was found.
+func (f Fact[T]) Known() bool {
+	return f.Confidence > 0
+}
+
+type SalaryRange struct {
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Currency string `json:"currency"`
+	Period   string `json:"period"`
+}
+
+// JobFacts are structured details extracted from a job description.
+type JobFacts struct {
+	Salary          Fact[SalaryRange] `json:"salary"`
+	VisaSponsorship Fact[bool]        `json:"visa_sponsorship"`
+	// RemoteRegions lists where a remote hire must live, e.g. "US" or "EU".
+	RemoteRegions   Fact[[]string] `json:"remote_regions"`
+	ExperienceYears Fact[int]      `json:"experience_years"`
+	TechStack       Fact[[]string] `json:"tech_stack"`
+	EmploymentType  Fact[string]   `json:"employment_type"`
+	// Model names the provider and model that answered, or HeuristicModel.
+	Model string `json:"-"`
+	Usage Usage  `json:"-"`
+}
+
+// parseFacts decodes an extraction answered by model.
+func parseFacts(response, model string) (JobFacts, error) {
+	var result JobFacts
+	if err := json.Unmarshal([]byte(cleanJSON(response)), &result); err != nil {
+		return JobFacts{}, fmt.Errorf("failed to parse job facts: %w (response: %s)", err, truncateText(response, 500))
+	}
+	result.sanitize()
+	result.Model = model
+	return result, nil
+}
+
+// JobFactsBatch answers an ExtractJobsFacts batch. Facts and Errs are indexed
+// like the jobs; a job with an Err can be retried on its own with
+// ExtractJobFacts.
+type JobFactsBatch struct {
+	Facts []JobFacts
+	Errs  []error
+	// Usage is the tokens of the whole request.
+	Usage Usage
+}
+
+func newJobFactsBatch(n int) JobFactsBatch {
+	return JobFactsBatch{Facts: make([]JobFacts, n), Errs: make([]error, n)}
+}
+
+// parseFactsBatch decodes an ExtractJobsFacts answer for n jobs answered by
+// model. Jobs that are missing from the answer get an error.
+func parseFactsBatch(response, model string, n int) (JobFactsBatch, error) {
+	var answer struct {
+		Jobs []struct {
+			Job int `json:"job"`
+			JobFacts
+		} `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(cleanJSON(response)), &answer); err != nil {
+		return JobFactsBatch{}, fmt.Errorf("failed to parse job facts: %w (response: %s)", err, truncateText(response, 500))
+	}
+
+	result := newJobFactsBatch(n)
+	answered := make([]bool, n)
+	for _, entry := range answer.Jobs {
+		i := entry.Job - 1
+		if i < 0 || i >= n || answered[i] {
+			continue
+		}
+		answered[i] = true
+		result.Facts[i] = entry.JobFacts
+		result.Facts[i].sanitize()
+		result.Facts[i].Model = model
+	}
+	for i := range answered {
+		if !answered[i] {
+			result.Errs[i] = fmt.Errorf("no answer for job %d of %d", i+1, n)
+		}
+	}
+	return result, nil
+}
+
+// sanitize normalizes values and drops the ones that are empty or implausible,
+// so a known fact always has a usable value.
+func (f *JobFacts) sanitize() {
+	s := &f.Salary.Value
+	s.Currency = strings.ToUpper(strings.TrimSpace(s.Currency))
+	s.Period = NormalizeSalaryPeriod(s.Period)
+	if s.Max < s.Min {
+		s.Max = s.Min
+	}
+	if s.Min < 0 || s.Max <= 0 {
+		f.Salary = Fact[SalaryRange]{}
+	}
+	f.RemoteRegions.Value = cleanList(f.RemoteRegions.Value)
+	if len(f.RemoteRegions.Value) == 0 {
+		f.RemoteRegions = Fact[[]string]{}
+	}
+	if f.ExperienceYears.Value <= 0 || f.ExperienceYears.Value > 40 {
+		f.ExperienceYears = Fact[int]{}
+	}
+	f.TechStack.Value = cleanList(f.TechStack.Value)
+	if len(f.TechStack.Value) == 0 {
+		f.TechStack = Fact[[]string]{}
+	}
+	f.EmploymentType.Value = NormalizeEmploymentType(f.EmploymentType.Value)
+	if f.EmploymentType.Value == "" {
+		f.EmploymentType = Fact[string]{}
+	}
+
+	f.Salary.Confidence = clampConfidence(f.Salary.Confidence)
+	f.VisaSponsorship.Confidence = clampConfidence(f.VisaSponsorship.Confidence)
+	f.RemoteRegions.Confidence = clampConfidence(f.RemoteRegions.Confidence)
+	f.ExperienceYears.Confidence = clampConfidence(f.ExperienceYears.Confidence)
+	f.TechStack.Confidence = clampConfidence(f.TechStack.Confidence)
+	f.EmploymentType.Confidence = clampConfidence(f.EmploymentType.Confidence)
+}
+
+func clampConfidence(c float64) float64 {
+	return min(max(c, 0), 1)
+}
+
+// cleanList trims entries and drops empty and duplicate ones.
+func cleanList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !slices.ContainsFunc(out, func(o string) bool { return strings.EqualFold(o, v) }) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// NormalizeEmploymentType maps the spellings used by boards and models
+// ("Full-time", "FullTime", "Contractor", ...) to the Employment constants,
+// or returns "" when it cannot tell.
+func NormalizeEmploymentType(value string) string {
+	v := strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(value))
+	switch {
+	case v == "":
+		return ""
+	case strings.Contains(v, "intern"):
+		return EmploymentInternship
+	case strings.Contains(v, "parttime"):
+		return EmploymentPartTime
+	case strings.Contains(v, "contract"), strings.Contains(v, "freelance"):
+		return EmploymentContract
+	case strings.Contains(v, "temp"):
+		return EmploymentTemporary
+	case strings.Contains(v, "fulltime"), v == "permanent":
+		return EmploymentFullTime
+	default:
+		return ""
+	}
+}
+
+// NormalizeSalaryPeriod maps pay intervals such as "per-hour-wage" or
+// "Monthly" to the Period constants, defaulting to PeriodYear.
+func NormalizeSalaryPeriod(value string) string {
+	switch v := strings.ToLower(strings.TrimSpace(value)); {
+	case strings.Contains(v, "hour"):
+		return PeriodHour
+	case strings.Contains(v, "month"):
+		return PeriodMonth
+	default:
+		return PeriodYear
+	}
+}
+
+// HeuristicFacts extracts job facts with fixed patterns. It is deterministic,
+// free and much less thorough than a model: the mock provider answers with
+// it, and ingestion falls back to it when extraction fails.
+func HeuristicFacts(job JobData) JobFacts {
+	text := job.Title + "\n" + job.Description
+	lower := strings.ToLower(text)
+	facts := JobFacts{Model: HeuristicModel}
+
+	if salary, ok := heuristicSalary(text); ok {
+		facts.Salary = Fact[SalaryRange]{Value: salary, Confidence: 0.6}
+	}
+	if sponsors, confidence := heuristicVisa(lower); confidence > 0 {
+		facts.VisaSponsorship = Fact[bool]{Value: sponsors, Confidence: confidence}
+	}
+	if regions := heuristicRegions(text); len(regions) > 0 {
+		facts.RemoteRegions = Fact[[]string]{Value: regions, Confidence: 0.5}
+	}
+	if years := heuristicYears(job.Description); years > 0 {
+		facts.ExperienceYears = Fact[int]{Value: years, Confidence: 0.6}
+	}
+	if stack := heuristicStack(text); len(stack) > 0 {
+		facts.TechStack = Fact[[]string]{Value: stack, Confidence: 0.6}
+	}
+	if kind := heuristicEmployment(strings.ToLower(job.Title)); kind != "" {
+		facts.EmploymentType = Fact[string]{Value: kind, Confidence: 0.8}
+	} else if kind := heuristicEmployment(lower); kind != "" {
+		facts.EmploymentType = Fact[string]{Value: kind, Confidence: 0.6}
+	}
+	return facts
+}
+
+const (
+	salaryAmount   = `(\d{1,3}(?:,\d{3})+|\d+(?:\.\d+)?)\s?(k)?`
+	salaryCurrency = `(?:[$€£]|(?:usd|eur|gbp|cad|aud)\s?)`
+)
+
+// salaryPattern matches "$120k - $150k", "USD 90,000 to 110,000", "€60k"
+// and, through salarySuffixPattern, "120-150k EUR".
+var (
+	salaryPattern       = regexp.MustCompile(`(?i)([$€£]|\b(?:usd|eur|gbp|cad|aud)\b)\s?` + salaryAmount + `(?:\s*(?:-|–|to)\s*` + salaryCurrency + `?` + salaryAmount + `)?`)
+	salarySuffixPattern = regexp.MustCompile(`(?i)\b` + salaryAmount + `\s*(?:-|–|to)\s*` + salaryAmount + `\s?(usd|eur|gbp|cad|aud)\b`)
+	hourlyPattern       = regexp.MustCompile(`(?i)^\s*(?:/\s*(?:h|hr|hour)\b|per hour|an hour|hourly)`)
+	monthlyPattern      = regexp.MustCompile(`(?i)^\s*(?:/\s*(?:mo|month)\b|per month|a month|monthly)`)
+)
+
+var currencySymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+
+// heuristicSalary returns the first plausible salary range in text. Amounts
+// under 10,000 only count when stated per hour or month.
+func heuristicSalary(text string) (SalaryRange, bool) {
+	type match struct {
+		end               int
+		currency, lo, loK string
+		hi, hiK           string
+	}
+	var matches []match
+	for _, m := range salaryPattern.FindAllStringSubmatchIndex(text, -1) {
+		matches = append(matches, match{end: m[1], currency: group(text, m, 1), lo: group(text, m, 2), loK: group(text, m, 3), hi: group(text, m, 4), hiK: group(text, m, 5)})
+	}
+	for _, m := range salarySuffixPattern.FindAllStringSubmatchIndex(text, -1) {
+		matches = append(matches, match{end: m[1], lo: group(text, m, 1), loK: group(text, m, 2), hi: group(text, m, 3), hiK: group(text, m, 4), currency: group(text, m, 5)})
+	}
+
+	for _, m := range matches {
+		if m.hi == "" {
+			m.hi, m.hiK = m.lo, m.loK
+		}
+		// "$120-150k" applies the k to both ends.
+		if m.loK == "" && m.hiK != "" {
+			m.loK = m.hiK
+		}
+		lo, hi := salaryValue(m.lo, m.loK), salaryValue(m.hi, m.hiK)
+		// So does "$120k-150".
+		if hi < lo && m.hiK == "" && m.loK != "" {
+			hi *= 1000
+		}
+		if lo <= 0 || hi < lo {
+			continue
+		}
+		period := PeriodYear
+		rest := text[m.end:]
+		switch {
+		case hourlyPattern.MatchString(rest):
+			period = PeriodHour
+		case monthlyPattern.MatchString(rest):
+			period = PeriodMonth
+		case hi < 10000:
+			continue
+		}
+		currency := currencySymbols[m.currency]
+		if currency == "" {
+			currency = strings.ToUpper(m.currency)
+		}
+		return SalaryRange{Min: lo, Max: hi, Currency: currency, Period: period}, true
+	}
+	return SalaryRange{}, false
+}
+
+// group returns submatch i of a FindAllStringSubmatchIndex match, or "".
+func group(text string, m []int, i int) string {
+	if m[2*i] < 0 {
+		return ""
+	}
+	return text[m[2*i]:m[2*i+1]]
+}
+
+func salaryValue(amount, k string) int {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(amount, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	if k != "" {
+		f *= 1000
+	}
+	return int(f)
+}
+
+// Visa phrases; the negative ones are checked first since "no visa
+// sponsorship" contains "visa sponsorship".
+var (
+	noVisaTerms = []string{
+		"no visa sponsorship", "not able to sponsor", "unable to sponsor", "cannot sponsor", "can't sponsor",
+		"can not sponsor", "do not sponsor", "does not sponsor", "will not sponsor", "won't sponsor",
+		"without sponsorship", "without visa sponsorship", "sponsorship is not available", "not offer visa sponsorship",
+		"not provide visa sponsorship", "not provide sponsorship",
+	}
+	visaTerms = []string{
+		"visa sponsorship available", "visa sponsorship is available", "we sponsor visas", "we offer visa sponsorship",
+		"will sponsor", "can sponsor", "visa support", "sponsorship available", "relocation and visa",
+	}
+)
+
+func heuristicVisa(lower string) (sponsors bool, confidence float64) {
+	for _, term := range noVisaTerms {
+		if strings.Contains(lower, term) {
+			return false, 0.7
+		}
+	}
+	for _, term := range visaTerms {
+		if strings.Contains(lower, term) {
+			return true, 0.7
+		}
+	}
+	if strings.Contains(lower, "must be authorized to work") || strings.Contains(lower, "must have the right to work") {
+		return false, 0.4
+	}
+	return false, 0
+}
+
+// regionPatterns recognize where remote hires must live. "US" needs context
+// since "us" is also a pronoun.
+var regionPatterns = []struct {
+	region string
+	re     *regexp.Regexp
+}{
+	{"US", regexp.MustCompile(`(?i)\b(?:usa|united states|u\.s\.(?:a\.)?|us[- ](?:based|only|citizens?|residents?|time ?zones?)|remote[ ,(:-]+us)\b|\(us\)`)},
+	{"CA", regexp.MustCompile(`(?i)\bcanada\b`)},
+	{"UK", regexp.MustCompile(`(?i)\b(?:uk|united kingdom)\b`)},
+	{"EU", regexp.MustCompile(`(?i)\b(?:eu|europe|european union|cet|cest)\b`)},
+	{"EMEA", regexp.MustCompile(`(?i)\bemea\b`)},
+	{"LATAM", regexp.MustCompile(`(?i)\b(?:latam|latin america|south america)\b`)},
+	{"APAC", regexp.MustCompile(`(?i)\b(?:apac|asia[- ]pacific)\b`)},
+	{"Americas", regexp.MustCompile(`(?i)\b(?:north america|americas)\b`)},
+}
+
+// restrictionMarkers make a sentence about where the hire may work.
+var restrictionMarkers = []string{"remote", "located in", "based in", "reside", "residents", "only", "time zone", "timezone", "hours overlap"}
+
+var sentenceSplit = regexp.MustCompile(`[.!?\n;]+\s*`)
+
+// heuristicRegions collects the regions named in sentences that restrict
+// where the hire may work. Worldwide remote postings yield "Worldwide".
+func heuristicRegions(text string) []string {
+	var regions []string
+	for _, sentence := range sentenceSplit.Split(text, -1) {
+		lower := strings.ToLower(sentence)
+		if strings.Contains(lower, "anywhere in the world") || strings.Contains(lower, "worldwide") {
+			return []string{"Worldwide"}
+		}
+		if !slices.ContainsFunc(restrictionMarkers, func(m string) bool { return strings.Contains(lower, m) }) {
+			continue
+		}
+		for _, p := range regionPatterns {
+			if p.re.MatchString(sentence) && !slices.Contains(regions, p.region) {
+				regions = append(regions, p.region)
+			}
+		}
+	}
+	return regions
+}
+
+var experiencePattern = regexp.MustCompile(`(?i)(\d{1,2})\s*(?:\+|plus)?\s*(?:(?:-|–|to)\s*\d{1,2}\s*\+?\s*)?years?(?:'|’)?(?:\s+of)?(?:\s+[\w/+#.-]+){0,3}?\s+(?:experience|exp\b)`)
+
+// heuristicYears returns the largest "N+ years of experience" requirement,
+// ignoring implausible numbers such as company age.
+func heuristicYears(text string) int {
+	years := 0
+	for _, m := range experiencePattern.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n > 20 {
+			continue
+		}
+		years = max(years, n)
+	}
+	return years
+}
+
+// techPatterns map technologies to their canonical name. Go needs context
+// since "go" is also a verb.
+var techPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"Go", regexp.MustCompile(`(?i)\bgolang\b|\bgo (?:developer|engineer|programming|language|services?)\b|\bin go\b|\(go\)|\bgo[,/]|[,/] ?go\b`)},
+	{"Python", regexp.MustCompile(`(?i)\bpython\b`)},
+	{"Java", regexp.MustCompile(`(?i)\bjava\b`)},
+	{"Kotlin", regexp.MustCompile(`(?i)\bkotlin\b`)},
+	{"Rust", regexp.MustCompile(`(?i)\brust\b`)},
+	{"TypeScript", regexp.MustCompile(`(?i)\btypescript\b`)},
+	{"JavaScript", regexp.MustCompile(`(?i)\bjavascript\b`)},
+	{"Node.js", regexp.MustCompile(`(?i)\bnode(?:\.?js)?\b`)},
+	{"React", regexp.MustCompile(`(?i)\breact\b`)},
+	{"Vue", regexp.MustCompile(`(?i)\bvue(?:\.?js)?\b`)},
+	{"Angular", regexp.MustCompile(`(?i)\bangular\b`)},
+	{"Ruby", regexp.MustCompile(`(?i)\bruby\b`)},
+	{"Rails", regexp.MustCompile(`(?i)\brails\b`)},
+	{"PHP", regexp.MustCompile(`(?i)\bphp\b`)},
+	{"C#", regexp.MustCompile(`(?i)\bc#|\.net\b`)},
+	{"C++", regexp.MustCompile(`(?i)\bc\+\+`)},
+	{"Scala", regexp.MustCompile(`(?i)\bscala\b`)},
+	{"Elixir", regexp.MustCompile(`(?i)\belixir\b`)},
+	{"PostgreSQL", regexp.MustCompile(`(?i)\bpostgres(?:ql)?\b`)},
+	{"MySQL", regexp.MustCompile(`(?i)\bmysql\b`)},
+	{"MongoDB", regexp.MustCompile(`(?i)\bmongo(?:db)?\b`)},
+	{"Redis", regexp.MustCompile(`(?i)\bredis\b`)},
+	{"Kafka", regexp.MustCompile(`(?i)\bkafka\b`)},
+	{"RabbitMQ", regexp.MustCompile(`(?i)\brabbitmq\b`)},
+	{"Elasticsearch", regexp.MustCompile(`(?i)\belastic(?:search)?\b`)},
+	{"Docker", regexp.MustCompile(`(?i)\bdocker\b`)},
+	{"Kubernetes", regexp.MustCompile(`(?i)\bkubernetes\b|\bk8s\b`)},
+	{"Terraform", regexp.MustCompile(`(?i)\bterraform\b`)},
+	{"AWS", regexp.MustCompile(`(?i)\baws\b|\bamazon web services\b`)},
+	{"GCP", regexp.MustCompile(`(?i)\bgcp\b|\bgoogle cloud\b`)},
+	{"Azure", regexp.MustCompile(`(?i)\bazure\b`)},
+	{"GraphQL", regexp.MustCompile(`(?i)\bgraphql\b`)},
+	{"gRPC", regexp.MustCompile(`(?i)\bgrpc\b`)},
+	{"Spark", regexp.MustCompile(`(?i)\bspark\b`)},
+	{"Airflow", regexp.MustCompile(`(?i)\bairflow\b`)},
+	{"Snowflake", regexp.MustCompile(`(?i)\bsnowflake\b`)},
+}
+
+func heuristicStack(text string) []string {
+	var stack []string
+	for _, t := range techPatterns {
+		if t.re.MatchString(text) {
+			stack = append(stack, t.name)
+		}
+	}
+	return stack
+}
+
+var employmentPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{EmploymentInternship, regexp.MustCompile(`(?i)\binternship\b|\bintern\b`)},
+	{EmploymentPartTime, regexp.MustCompile(`(?i)\bpart[- ]?time\b`)},
+	{EmploymentContract, regexp.MustCompile(`(?i)\bcontract(?:or)?\b|\bfreelance\b`)},
+	{EmploymentTemporary, regexp.MustCompile(`(?i)\btemporary\b`)},
+	{EmploymentFullTime, regexp.MustCompile(`(?i)\bfull[- ]?time\b|\bpermanent\b`)},
+}
+
+// heuristicEmployment returns the employment type mentioned first in text.
+func heuristicEmployment(text string) string {
+	kind, first := "", -1
+	for _, p := range employmentPatterns {
+		if loc := p.re.FindStringIndex(text); loc != nil && (first < 0 || loc[0] < first) {
+			kind, first = p.kind, loc[0]
+		}
+	}
+	return kind
+}
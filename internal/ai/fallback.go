@@ -0,0 +1,258 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// APIError is an error response from a provider's API.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error: %s (status: %d)", e.Provider, e.Message, e.StatusCode)
+}
+
+// Retryable reports whether err means the provider is unavailable right now
+// (quota exhausted, timed out or failing server side), so another provider
+// should be tried. Bad responses from a healthy provider are not retryable.
+func Retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Provider cooldowns double with every consecutive retryable failure.
+const (
+	providerCooldown    = 30 * time.Second
+	providerMaxCooldown = 15 * time.Minute
+)
+
+// ProviderStatus is the health of one provider/model as seen by this process.
+type ProviderStatus struct {
+	Healthy bool `json:"healthy"`
+	// Failures counts consecutive retryable failures.
+	Failures    int        `json:"failures"`
+	Calls       uint64     `json:"calls"`
+	Fallbacks   uint64     `json:"fallbacks"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// DownUntil is when a failing provider is tried first again.
+	DownUntil *time.Time `json:"down_until,omitempty"`
+}
+
+// providerHealth tracks the providers of one or more chains, keyed by
+// Client.Model(). NewClient shares one between all the chains it builds, so a
+// quota hit while matching also moves classification off the provider.
+type providerHealth struct {
+	mu       sync.Mutex
+	statuses map[string]*ProviderStatus
+}
+
+func newProviderHealth() *providerHealth {
+	return &providerHealth{statuses: map[string]*ProviderStatus{}}
+}
+
+func (h *providerHealth) snapshot() map[string]ProviderStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make(map[string]ProviderStatus, len(h.statuses))
+	for name, status := range h.statuses {
+		out[name] = *status
+	}
+	return out
+}
+
+func (h *providerHealth) status(name string) *ProviderStatus {
+	status, ok := h.statuses[name]
+	if !ok {
+		status = &ProviderStatus{Healthy: true}
+		h.statuses[name] = status
+	}
+	return status
+}
+
+func (h *providerHealth) available(name string, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := h.status(name)
+	return status.DownUntil == nil || !now.Before(*status.DownUntil)
+}
+
+// record updates the provider's health after a call. Only retryable errors
+// count against it.
+func (h *providerHealth) record(name string, err error, fellBack bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := h.status(name)
+	status.Calls++
+	if fellBack {
+		status.Fallbacks++
+	}
+	if err == nil {
+		status.Healthy, status.Failures, status.DownUntil = true, 0, nil
+		return
+	}
+	now := time.Now()
+	status.LastError, status.LastErrorAt = err.Error(), &now
+	if !Retryable(err) {
+		return
+	}
+	status.Failures++
+	cooldown := min(providerCooldown<<min(status.Failures-1, 10), providerMaxCooldown)
+	until := now.Add(cooldown)
+	status.Healthy, status.DownUntil = false, &until
+}
+
+// ProviderHealth returns the health of every provider the fallback chains
+// behind c have called.
+func ProviderHealth(c Client) map[string]ProviderStatus {
+	out := map[string]ProviderStatus{}
+	switch c := c.(type) {
+	case *FallbackClient:
+		maps.Copy(out, c.Health())
+	case *RoutedClient:
+		for _, op := range []Client{c.classify, c.match, c.extract} {
+			maps.Copy(out, ProviderHealth(op))
+		}
+	}
+	return out
+}
+
+// FallbackClient tries its providers in order. A provider that fails with a
+// retryable error is skipped until its cooldown ends; when every provider is
+// cooling down they are all tried in order anyway.
+type FallbackClient struct {
+	providers []Client
+	health    *providerHealth
+}
+
+func NewFallbackClient(providers ...Client) *FallbackClient {
+	return &FallbackClient{providers: providers, health: newProviderHealth()}
+}
+
+// Health returns the health of every provider this chain has called.
+func (f *FallbackClient) Health() map[string]ProviderStatus {
+	return f.health.snapshot()
+}
+
+// Model names the preferred provider. Results name the one that answered.
+func (f *FallbackClient) Model() string {
+	return f.providers[0].Model()
+}
+
+func (f *FallbackClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error) {
+	return callChain(ctx, f, func(c Client) (WebsiteClassification, error) {
+		return c.ClassifyWebsite(ctx, data)
+	})
+}
+
+func (f *FallbackClient) MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error) {
+	return callChain(ctx, f, func(c Client) (JobMatch, error) {
+		return c.MatchJob(ctx, job, profile)
+	})
+}
+
+func (f *FallbackClient) MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error) {
+	return callChain(ctx, f, func(c Client) (JobMatches, error) {
+		return c.MatchJobs(ctx, jobs, profile)
+	})
+}
+
+func (f *FallbackClient) ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error) {
+	return callChain(ctx, f, func(c Client) (JobFacts, error) {
+		return c.ExtractJobFacts(ctx, job)
+	})
+}
+
+func (f *FallbackClient) ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error) {
+	return callChain(ctx, f, func(c Client) (JobFactsBatch, error) {
+		return c.ExtractJobsFacts(ctx, jobs)
+	})
+}
+
+func callChain[T any](ctx context.Context, f *FallbackClient, call func(Client) (T, error)) (T, error) {
+	now := time.Now()
+	order := make([]Client, 0, len(f.providers))
+	for _, p := range f.providers {
+		if f.health.available(p.Model(), now) {
+			order = append(order, p)
+		}
+	}
+	if len(order) == 0 {
+		order = f.providers
+	}
+
+	var (
+		zero T
+		errs []error
+	)
+	for i, p := range order {
+		result, err := call(p)
+		f.health.record(p.Model(), err, i > 0)
+		if err == nil {
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Model(), err))
+		if ctx.Err() != nil || !Retryable(err) {
+			break
+		}
+		if i+1 < len(order) {
+			slog.Warn("ai provider unavailable, falling back", "provider", p.Model(), "next", order[i+1].Model(), "error", err)
+		}
+	}
+	return zero, errors.Join(errs...)
+}
+
+// RoutedClient sends each operation to its own client, e.g. a cheap model
+// for classification and extraction and a stronger chain for matching.
+type RoutedClient struct {
+	classify Client
+	match    Client
+	extract  Client
+}
+
+func NewRoutedClient(classify, match, extract Client) *RoutedClient {
+	return &RoutedClient{classify: classify, match: match, extract: extract}
+}
+
+// Model names the client behind MatchJob, whose scores are stored.
+func (r *RoutedClient) Model() string {
+	return r.match.Model()
+}
+
+func (r *RoutedClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error) {
+	return r.classify.ClassifyWebsite(ctx, data)
+}
+
+func (r *RoutedClient) MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error) {
+	return r.match.MatchJob(ctx, job, profile)
+}
+
+func (r *RoutedClient) MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error) {
+	return r.match.MatchJobs(ctx, jobs, profile)
+}
+
+func (r *RoutedClient) ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error) {
+	return r.extract.ExtractJobFacts(ctx, job)
+}
+
+func (r *RoutedClient) ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error) {
+	return r.extract.ExtractJobsFacts(ctx, jobs)
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -69,13 +68,21 @@ type geminiResponse struct {
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
 	Error *struct {
 		Message string `json:"message"`
 		Code    int    `json:"code"`
 	} `json:"error,omitempty"`
 }
 
-func (g *GeminiClient) callAPI(ctx context.Context, prompt string) (string, error) {
+func (g *GeminiClient) Model() string {
+	return "gemini/" + g.model
+}
+
+func (g *GeminiClient) callAPI(ctx context.Context, prompt string, maxTokens int) (string, Usage, error) {
 	url := fmt.Sprintf("%s/%s:generateContent?key=%s", geminiBaseURL, g.model, g.apiKey)
 
 	reqBody := geminiRequest{
@@ -88,145 +95,127 @@ func (g *GeminiClient) callAPI(ctx context.Context, prompt string) (string, erro
 		},
 		GenerationConfig: geminiGenerationConfig{
 			Temperature:      0.1, // Low temperature for consistent JSON output
-			MaxOutputTokens:  500,
+			MaxOutputTokens:  maxTokens,
 			ResponseMIMEType: "application/json",
 		},
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", Usage{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", Usage{}, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := g.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("API request failed: %w", err)
+		return "", Usage{}, fmt.Errorf("API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", Usage{}, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var geminiResp geminiResponse
 	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		if resp.StatusCode != http.StatusOK {
+			return "", Usage{}, &APIError{Provider: "Gemini", StatusCode: resp.StatusCode, Message: truncateText(string(body), 200)}
+		}
+		return "", Usage{}, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if geminiResp.Error != nil {
-		return "", fmt.Errorf("Gemini API error: %s (code: %d)", geminiResp.Error.Message, geminiResp.Error.Code)
+		return "", Usage{}, &APIError{Provider: "Gemini", StatusCode: geminiResp.Error.Code, Message: geminiResp.Error.Message}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", Usage{}, &APIError{Provider: "Gemini", StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
 	}
 
+	usage := Usage{
+		InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
+		OutputTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
+	}
 	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from Gemini")
+		return "", usage, fmt.Errorf("empty response from Gemini")
 	}
 
-	return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+	return geminiResp.Candidates[0].Content.Parts[0].Text, usage, nil
 }
 
 // ClassifyWebsite uses Gemini to classify if a webpage is a job listing source.
 func (g *GeminiClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error) {
-	prompt := fmt.Sprintf(`You are a strict website classifier.
-
-Given a webpage snapshot, classify whether this page is a job listing source.
+	prompt := classifyPrompt(data)
 
-Return JSON only with this exact structure:
-{
-  "is_job_site": boolean,
-  "tech_related": boolean,
-  "confidence": number between 0 and 1,
-  "reason": "short explanation"
-}
-
-Rules:
-- "is_job_site" = true if the page contains job postings or career listings
-- "tech_related" = true if jobs are software, engineering, IT, or tech-related
-- "confidence" from 0 to 1 (how confident you are)
-- "reason" = short explanation (max 50 words)
-
-Webpage data:
-URL: %s
-Title: %s
-Meta description: %s
-Text sample:
-%s`, data.URL, data.Title, data.MetaDescription, truncateText(data.TextSample, 500))
-
-	response, err := g.callAPI(ctx, prompt)
+	response, usage, err := g.callAPI(ctx, prompt, answerMaxTokens)
 	if err != nil {
-		return WebsiteClassification{}, err
-	}
-
-	var result WebsiteClassification
-	if err := json.Unmarshal([]byte(cleanJSON(response)), &result); err != nil {
-		return WebsiteClassification{}, fmt.Errorf("failed to parse classification: %w (response: %s)", err, response)
+		return WebsiteClassification{Usage: usage}, err
 	}
 
-	return result, nil
+	result, err := parseClassification(response, g.Model())
+	result.Usage = usage
+	return result, err
 }
 
 // MatchJob uses Gemini to score how well a job matches a candidate profile.
 func (g *GeminiClient) MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error) {
-	techStack := strings.Join(profile.TechStack, ", ")
+	prompt := matchPrompt(job, profile)
 
-	prompt := fmt.Sprintf(`You are a job matching assistant.
-
-Analyze how well this job matches the candidate's profile and return a JSON score.
+	response, usage, err := g.callAPI(ctx, prompt, answerMaxTokens)
+	if err != nil {
+		return JobMatch{Usage: usage}, err
+	}
 
-Return JSON only with this exact structure:
-{
-  "match_score": number from 0 to 100,
-  "strengths": ["strength1", "strength2"],
-  "weaknesses": ["weakness1"],
-  "short_summary": "one sentence summary"
+	result, err := parseMatch(response, g.Model())
+	result.Usage = usage
+	return result, err
 }
 
-Rules:
-- match_score: 90-100 = excellent fit, 70-89 = good fit, 50-69 = partial fit, below 50 = poor fit
-- Focus on Go/Golang, backend, and the candidate's tech stack
-- strengths: what makes this a good match (max 3 items)
-- weaknesses: what might be missing (max 2 items)
-- short_summary: one sentence (max 20 words)
+// MatchJobs uses Gemini to score several jobs against one profile in a
+// single request.
+func (g *GeminiClient) MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error) {
+	prompt := matchBatchPrompt(jobs, profile)
 
-Candidate's Tech Stack: %s
+	response, usage, err := g.callAPI(ctx, prompt, matchBatchMaxTokens(len(jobs)))
+	if err != nil {
+		return JobMatches{Usage: usage}, err
+	}
 
-Job Title: %s
+	result, err := parseMatches(response, g.Model(), len(jobs))
+	result.Usage = usage
+	return result, err
+}
 
-Job Description:
-%s`, techStack, job.Title, truncateText(job.Description, 800))
+// ExtractJobFacts uses Gemini to read structured facts from a job posting.
+func (g *GeminiClient) ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error) {
+	prompt := extractPrompt(job)
 
-	response, err := g.callAPI(ctx, prompt)
+	response, usage, err := g.callAPI(ctx, prompt, answerMaxTokens)
 	if err != nil {
-		return JobMatch{}, err
+		return JobFacts{Usage: usage}, err
 	}
 
-	var result JobMatch
-	if err := json.Unmarshal([]byte(cleanJSON(response)), &result); err != nil {
-		return JobMatch{}, fmt.Errorf("failed to parse match result: %w (response: %s)", err, response)
-	}
-
-	return result, nil
+	result, err := parseFacts(response, g.Model())
+	result.Usage = usage
+	return result, err
 }
 
-// truncateText limits text to maxLen characters
-func truncateText(text string, maxLen int) string {
-	if len(text) <= maxLen {
-		return text
+// ExtractJobsFacts uses Gemini to read the facts of several job postings in
+// a single request.
+func (g *GeminiClient) ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error) {
+	prompt := extractBatchPrompt(jobs)
+
+	response, usage, err := g.callAPI(ctx, prompt, extractBatchMaxTokens(len(jobs)))
+	if err != nil {
+		return JobFactsBatch{Usage: usage}, err
 	}
-	return text[:maxLen] + "..."
-}
 
-// cleanJSON removes markdown code blocks if present
-func cleanJSON(s string) string {
-	s = strings.TrimSpace(s)
-	s = strings.TrimPrefix(s, "```json")
-	s = strings.TrimPrefix(s, "```")
-	s = strings.TrimSuffix(s, "```")
-	return strings.TrimSpace(s)
+	result, err := parseFactsBatch(response, g.Model(), len(jobs))
+	result.Usage = usage
+	return result, err
 }
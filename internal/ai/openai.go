@@ -0,0 +1,214 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	openAIBaseURL      = "https://api.openai.com/v1"
+	openAIDefaultModel = "gpt-4o-mini"
+)
+
+// OpenAIClient implements the Client interface against any server speaking
+// the OpenAI chat completions API: OpenAI itself, llama.cpp's server, vLLM,
+// Ollama's /v1 endpoint and most hosted inference services.
+type OpenAIClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	jsonMode   bool
+	httpClient *http.Client
+}
+
+// NewOpenAIClient creates a client for the chat completions API under
+// baseURL (e.g. "http://localhost:11434/v1"). apiKey may be empty for local
+// servers that do not check it. JSON mode is on by default.
+func NewOpenAIClient(baseURL, apiKey, model string) *OpenAIClient {
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+	if model == "" {
+		model = openAIDefaultModel
+	}
+	return &OpenAIClient{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		apiKey:   apiKey,
+		model:    model,
+		jsonMode: true,
+		httpClient: &http.Client{
+			// Local models on modest hardware can take a while per answer.
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// WithJSONMode turns response_format {"type": "json_object"} on or off. Some
+// compatible servers reject the field; the prompts ask for JSON either way.
+func (o *OpenAIClient) WithJSONMode(enabled bool) *OpenAIClient {
+	o.jsonMode = enabled
+	return o
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    float64               `json:"temperature"`
+	MaxTokens      int                   `json:"max_tokens"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error,omitempty"`
+}
+
+func (o *OpenAIClient) Model() string {
+	return "openai/" + o.model
+}
+
+func (o *OpenAIClient) callAPI(ctx context.Context, prompt string, maxTokens int) (string, Usage, error) {
+	reqBody := openAIRequest{
+		Model: o.model,
+		Messages: []openAIMessage{
+			{Role: "system", Content: "You answer with a single JSON object and nothing else."},
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.1, // Low temperature for consistent JSON output
+		MaxTokens:   maxTokens,
+	}
+	if o.jsonMode {
+		reqBody.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var openAIResp openAIResponse
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", Usage{}, &APIError{Provider: "OpenAI", StatusCode: resp.StatusCode, Message: truncateText(string(body), 200)}
+		}
+		return "", Usage{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if openAIResp.Error != nil {
+		return "", Usage{}, &APIError{Provider: "OpenAI", StatusCode: resp.StatusCode, Message: openAIResp.Error.Message}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", Usage{}, &APIError{Provider: "OpenAI", StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+
+	usage := Usage{
+		InputTokens:  openAIResp.Usage.PromptTokens,
+		OutputTokens: openAIResp.Usage.CompletionTokens,
+	}
+	if len(openAIResp.Choices) == 0 || openAIResp.Choices[0].Message.Content == "" {
+		return "", usage, fmt.Errorf("empty response from %s", o.baseURL)
+	}
+
+	return openAIResp.Choices[0].Message.Content, usage, nil
+}
+
+// ClassifyWebsite asks the model to classify if a webpage is a job listing source.
+func (o *OpenAIClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error) {
+	response, usage, err := o.callAPI(ctx, classifyPrompt(data), answerMaxTokens)
+	if err != nil {
+		return WebsiteClassification{Usage: usage}, err
+	}
+	result, err := parseClassification(response, o.Model())
+	result.Usage = usage
+	return result, err
+}
+
+// MatchJob asks the model to score how well a job matches a candidate profile.
+func (o *OpenAIClient) MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error) {
+	response, usage, err := o.callAPI(ctx, matchPrompt(job, profile), answerMaxTokens)
+	if err != nil {
+		return JobMatch{Usage: usage}, err
+	}
+	result, err := parseMatch(response, o.Model())
+	result.Usage = usage
+	return result, err
+}
+
+// MatchJobs asks the model to score several jobs against one profile in a
+// single request.
+func (o *OpenAIClient) MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error) {
+	response, usage, err := o.callAPI(ctx, matchBatchPrompt(jobs, profile), matchBatchMaxTokens(len(jobs)))
+	if err != nil {
+		return JobMatches{Usage: usage}, err
+	}
+	result, err := parseMatches(response, o.Model(), len(jobs))
+	result.Usage = usage
+	return result, err
+}
+
+// ExtractJobFacts asks the model to read structured facts from a job posting.
+func (o *OpenAIClient) ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error) {
+	response, usage, err := o.callAPI(ctx, extractPrompt(job), answerMaxTokens)
+	if err != nil {
+		return JobFacts{Usage: usage}, err
+	}
+	result, err := parseFacts(response, o.Model())
+	result.Usage = usage
+	return result, err
+}
+
+// ExtractJobsFacts asks the model to read the facts of several job postings
+// in a single request.
+func (o *OpenAIClient) ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error) {
+	response, usage, err := o.callAPI(ctx, extractBatchPrompt(jobs), extractBatchMaxTokens(len(jobs)))
+	if err != nil {
+		return JobFactsBatch{Usage: usage}, err
+	}
+	result, err := parseFactsBatch(response, o.Model(), len(jobs))
+	result.Usage = usage
+	return result, err
+}
@@ -0,0 +1,318 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// openAIServer answers every chat completion with content, and records the
+// last request so tests can check what the client sent.
+type openAIServer struct {
+	*httptest.Server
+	status  int
+	body    string
+	auth    string
+	request openAIRequest
+}
+
+func newOpenAIServer(t *testing.T, content string) *openAIServer {
+	t.Helper()
+	s := &openAIServer{status: http.StatusOK, body: completion(content)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request = %s %s, want POST /v1/chat/completions", r.Method, r.URL.Path)
+		}
+		s.auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&s.request); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(s.status)
+		_, _ = w.Write([]byte(s.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func completion(content string) string {
+	body, _ := json.Marshal(map[string]any{
+		"choices": []map[string]any{
+			{"message": map[string]string{"role": "assistant", "content": content}},
+		},
+		"usage": map[string]int{"prompt_tokens": 120, "completion_tokens": 30},
+	})
+	return string(body)
+}
+
+func TestOpenAIClassifyWebsite(t *testing.T) {
+	srv := newOpenAIServer(t, `{"is_job_site": true, "tech_related": true, "confidence": 0.9, "reason": "careers page"}`)
+	client := NewOpenAIClient(srv.URL+"/v1", "sk-test", "llama3.1")
+
+	got, err := client.ClassifyWebsite(context.Background(), WebsiteData{URL: "https://example.com/careers", Title: "Careers"})
+	if err != nil {
+		t.Fatalf("ClassifyWebsite: %v", err)
+	}
+	if !got.IsJobSite || !got.TechRelated || got.Confidence != 0.9 || got.Reason != "careers page" {
+		t.Errorf("classification = %+v", got)
+	}
+	if got.Model != "openai/llama3.1" {
+		t.Errorf("Model = %q, want openai/llama3.1", got.Model)
+	}
+	if got.Usage != (Usage{InputTokens: 120, OutputTokens: 30}) {
+		t.Errorf("Usage = %+v", got.Usage)
+	}
+	if srv.request.Model != "llama3.1" {
+		t.Errorf("request model = %q, want llama3.1", srv.request.Model)
+	}
+	if n := len(srv.request.Messages); n != 2 || !strings.Contains(srv.request.Messages[1].Content, "https://example.com/careers") {
+		t.Errorf("request messages = %+v", srv.request.Messages)
+	}
+}
+
+func TestOpenAIMatchJob(t *testing.T) {
+	srv := newOpenAIServer(t, `{"match_score": 82, "strengths": ["Go"], "weaknesses": ["no k8s"], "short_summary": "good fit"}`)
+	client := NewOpenAIClient(srv.URL+"/v1", "sk-test", "gpt-4o-mini")
+
+	got, err := client.MatchJob(context.Background(), JobData{Title: "Backend Engineer", Description: "Go and Postgres"}, CandidateProfile{TechStack: []string{"go"}})
+	if err != nil {
+		t.Fatalf("MatchJob: %v", err)
+	}
+	if got.MatchScore != 82 || got.ShortSummary != "good fit" || len(got.Strengths) != 1 || len(got.Weaknesses) != 1 {
+		t.Errorf("match = %+v", got)
+	}
+	if got.Model != "openai/gpt-4o-mini" || got.PromptVersion != MatchPromptVersion {
+		t.Errorf("Model, PromptVersion = %q, %q", got.Model, got.PromptVersion)
+	}
+}
+
+func TestOpenAIMatchJobs(t *testing.T) {
+	srv := newOpenAIServer(t, `{"matches": [
+		{"job": 2, "match_score": 40, "strengths": [], "weaknesses": ["php"], "short_summary": "weak"},
+		{"job": 1, "match_score": 90, "strengths": ["go"], "weaknesses": [], "short_summary": "strong"},
+		{"job": 3, "match_score": 140, "short_summary": "out of range"}
+	]}`)
+	client := NewOpenAIClient(srv.URL+"/v1", "sk-test", "gpt-4o-mini")
+
+	jobs := []JobData{{Title: "Go"}, {Title: "PHP"}, {Title: "Rust"}, {Title: "Java"}}
+	got, err := client.MatchJobs(context.Background(), jobs, CandidateProfile{TechStack: []string{"go"}})
+	if err != nil {
+		t.Fatalf("MatchJobs: %v", err)
+	}
+	if len(got.Matches) != 4 || len(got.Errs) != 4 {
+		t.Fatalf("got %d matches and %d errs, want 4 each", len(got.Matches), len(got.Errs))
+	}
+	if got.Errs[0] != nil || got.Matches[0].MatchScore != 90 {
+		t.Errorf("job 1 = %+v, %v", got.Matches[0], got.Errs[0])
+	}
+	if got.Errs[1] != nil || got.Matches[1].MatchScore != 40 {
+		t.Errorf("job 2 = %+v, %v", got.Matches[1], got.Errs[1])
+	}
+	if got.Matches[0].PromptVersion != MatchBatchPromptVersion {
+		t.Errorf("PromptVersion = %q, want %q", got.Matches[0].PromptVersion, MatchBatchPromptVersion)
+	}
+	if got.Errs[2] == nil {
+		t.Error("job 3 scored out of range, want an error")
+	}
+	if got.Errs[3] == nil {
+		t.Error("job 4 got no answer, want an error")
+	}
+	if srv.request.MaxTokens != matchBatchMaxTokens(len(jobs)) {
+		t.Errorf("max_tokens = %d, want %d", srv.request.MaxTokens, matchBatchMaxTokens(len(jobs)))
+	}
+}
+
+func TestOpenAIExtractJobsFacts(t *testing.T) {
+	srv := newOpenAIServer(t, `{"jobs": [
+		{"job": 2, "employment_type": {"value": "Contractor", "confidence": 0.9}},
+		{"job": 1, "salary": {"value": {"min": 120000, "max": 150000, "currency": "usd", "period": "yearly"}, "confidence": 0.8}}
+	]}`)
+	client := NewOpenAIClient(srv.URL+"/v1", "sk-test", "gpt-4o-mini")
+
+	jobs := []JobData{{Title: "Go"}, {Title: "PHP"}, {Title: "Rust"}}
+	got, err := client.ExtractJobsFacts(context.Background(), jobs)
+	if err != nil {
+		t.Fatalf("ExtractJobsFacts: %v", err)
+	}
+	if len(got.Facts) != 3 || len(got.Errs) != 3 {
+		t.Fatalf("got %d facts and %d errs, want 3 each", len(got.Facts), len(got.Errs))
+	}
+	if salary := got.Facts[0].Salary; got.Errs[0] != nil || salary.Value != (SalaryRange{Min: 120000, Max: 150000, Currency: "USD", Period: PeriodYear}) {
+		t.Errorf("job 1 = %+v, %v", got.Facts[0], got.Errs[0])
+	}
+	if got.Errs[1] != nil || got.Facts[1].EmploymentType.Value != EmploymentContract {
+		t.Errorf("job 2 = %+v, %v", got.Facts[1], got.Errs[1])
+	}
+	if got.Facts[0].Model != "openai/gpt-4o-mini" {
+		t.Errorf("Model = %q, want openai/gpt-4o-mini", got.Facts[0].Model)
+	}
+	if got.Errs[2] == nil {
+		t.Error("job 3 got no answer, want an error")
+	}
+	if srv.request.MaxTokens != extractBatchMaxTokens(len(jobs)) {
+		t.Errorf("max_tokens = %d, want %d", srv.request.MaxTokens, extractBatchMaxTokens(len(jobs)))
+	}
+}
+
+func TestOpenAIAuthorization(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		want   string
+	}{
+		{"with key", "sk-test", "Bearer sk-test"},
+		{"local server without key", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, `{"is_job_site": false}`)
+			client := NewOpenAIClient(srv.URL+"/v1", tt.apiKey, "")
+			if _, err := client.ClassifyWebsite(context.Background(), WebsiteData{}); err != nil {
+				t.Fatalf("ClassifyWebsite: %v", err)
+			}
+			if srv.auth != tt.want {
+				t.Errorf("Authorization = %q, want %q", srv.auth, tt.want)
+			}
+			if srv.request.Model != openAIDefaultModel {
+				t.Errorf("request model = %q, want %q", srv.request.Model, openAIDefaultModel)
+			}
+		})
+	}
+}
+
+func TestOpenAIResponseFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonMode bool
+	}{
+		{"json mode", true},
+		{"plain", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, `{"is_job_site": true}`)
+			client := NewOpenAIClient(srv.URL+"/v1", "", "").WithJSONMode(tt.jsonMode)
+			if _, err := client.ClassifyWebsite(context.Background(), WebsiteData{}); err != nil {
+				t.Fatalf("ClassifyWebsite: %v", err)
+			}
+			format := srv.request.ResponseFormat
+			if !tt.jsonMode {
+				if format != nil {
+					t.Errorf("response_format = %+v, want it omitted", format)
+				}
+				return
+			}
+			if format == nil || format.Type != "json_object" {
+				t.Errorf("response_format = %+v, want json_object", format)
+			}
+		})
+	}
+}
+
+func TestOpenAIParsesWrappedJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"fenced", "```json\n{\"match_score\": 75, \"short_summary\": \"ok\"}\n```"},
+		{"bare fence", "```\n{\"match_score\": 75, \"short_summary\": \"ok\"}\n```"},
+		{"chatty", "Sure! Here is the assessment:\n{\"match_score\": 75, \"short_summary\": \"ok\"}\nLet me know if you need more."},
+		{"chatty and fenced", "Here you go:\n```json\n{\"match_score\": 75, \"short_summary\": \"ok\"}\n```\nHope this helps."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, tt.content)
+			client := NewOpenAIClient(srv.URL+"/v1", "", "").WithJSONMode(false)
+			got, err := client.MatchJob(context.Background(), JobData{Title: "Go"}, CandidateProfile{})
+			if err != nil {
+				t.Fatalf("MatchJob: %v", err)
+			}
+			if got.MatchScore != 75 || got.ShortSummary != "ok" {
+				t.Errorf("match = %+v", got)
+			}
+		})
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantCode  int
+		wantMsg   string
+		retryable bool
+	}{
+		{
+			name:      "rate limited with error body",
+			status:    http.StatusTooManyRequests,
+			body:      `{"error": {"message": "Rate limit reached", "type": "requests"}}`,
+			wantCode:  http.StatusTooManyRequests,
+			wantMsg:   "Rate limit reached",
+			retryable: true,
+		},
+		{
+			name:      "server error with plain body",
+			status:    http.StatusBadGateway,
+			body:      "upstream unavailable",
+			wantCode:  http.StatusBadGateway,
+			wantMsg:   "upstream unavailable",
+			retryable: true,
+		},
+		{
+			name:     "bad request with error body",
+			status:   http.StatusBadRequest,
+			body:     `{"error": {"message": "response_format is not supported", "type": "invalid_request_error"}}`,
+			wantCode: http.StatusBadRequest,
+			wantMsg:  "response_format is not supported",
+		},
+		{
+			name:     "error body with status 200",
+			status:   http.StatusOK,
+			body:     `{"error": {"message": "model not loaded", "type": "server_error"}}`,
+			wantCode: http.StatusOK,
+			wantMsg:  "model not loaded",
+		},
+		{
+			name:      "non-200 without error body",
+			status:    http.StatusServiceUnavailable,
+			body:      `{}`,
+			wantCode:  http.StatusServiceUnavailable,
+			wantMsg:   http.StatusText(http.StatusServiceUnavailable),
+			retryable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, "")
+			srv.status, srv.body = tt.status, tt.body
+			client := NewOpenAIClient(srv.URL+"/v1", "sk-test", "")
+
+			_, err := client.MatchJob(context.Background(), JobData{Title: "Go"}, CandidateProfile{})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.wantCode || apiErr.Message != tt.wantMsg {
+				t.Errorf("APIError = %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, tt.wantCode, tt.wantMsg)
+			}
+			if Retryable(err) != tt.retryable {
+				t.Errorf("Retryable = %v, want %v", Retryable(err), tt.retryable)
+			}
+		})
+	}
+}
+
+func TestOpenAIEmptyAnswer(t *testing.T) {
+	srv := newOpenAIServer(t, "")
+	srv.body = `{"choices": []}`
+	client := NewOpenAIClient(srv.URL+"/v1", "", "")
+
+	if _, err := client.MatchJobs(context.Background(), []JobData{{Title: "Go"}}, CandidateProfile{}); err == nil {
+		t.Fatal("MatchJobs with no choices succeeded, want an error")
+	}
+}
@@ -0,0 +1,289 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The prompts and response parsing below are shared by every provider so
+// scores do not depend on which one produced them.
+
+// classifyPrompt asks for a WebsiteClassification as JSON.
+func classifyPrompt(data WebsiteData) string {
+	return fmt.Sprintf(`You are a strict website classifier.
+
+Given a webpage snapshot, classify whether this page is a job listing source.
+
+Return JSON only with this exact structure:
+{
+  "is_job_site": boolean,
+  "tech_related": boolean,
+  "confidence": number between 0 and 1,
+  "reason": "short explanation"
+}
+
+Rules:
+- "is_job_site" = true if the page contains job postings or career listings
+- "tech_related" = true if jobs are software, engineering, IT, or tech-related
+- "confidence" from 0 to 1 (how confident you are)
+- "reason" = short explanation (max 50 words)
+
+Webpage data:
+URL: %s
+Title: %s
+Meta description: %s
+Text sample:
+%s`, data.URL, data.Title, data.MetaDescription, truncateText(data.TextSample, 500))
+}
+
+// matchPrompt asks for a JobMatch as JSON. Changing it requires bumping
+// MatchPromptVersion.
+func matchPrompt(job JobData, profile CandidateProfile) string {
+	return fmt.Sprintf(`You are a job matching assistant.
+
+Analyze how well this job matches the candidate's profile and return a JSON score.
+
+Return JSON only with this exact structure:
+{
+  "match_score": number from 0 to 100,
+  "strengths": ["strength1", "strength2"],
+  "weaknesses": ["weakness1"],
+  "short_summary": "one sentence summary"
+}
+
+Rules:
+- match_score: 90-100 = excellent fit, 70-89 = good fit, 50-69 = partial fit, below 50 = poor fit
+- Focus on the candidate's tech stack, target roles and stated preferences
+- strengths: what makes this a good match (max 3 items)
+- weaknesses: what might be missing (max 2 items)
+- short_summary: one sentence (max 20 words)
+
+%s
+Job Title: %s
+
+Job Description:
+%s`, describeProfile(profile), job.Title, truncateText(job.Description, matchDescriptionLimit))
+}
+
+// matchDescriptionLimit is how much of a description the match prompts
+// show. Single and batched matches share it so a job is judged on the same
+// text either way.
+const matchDescriptionLimit = 800
+
+// matchBatchPrompt asks for one JobMatch per job as a JSON array. Changing
+// it requires bumping MatchBatchPromptVersion.
+func matchBatchPrompt(jobs []JobData, profile CandidateProfile) string {
+	var sb strings.Builder
+	for i, job := range jobs {
+		fmt.Fprintf(&sb, "\nJob %d Title: %s\nJob %d Description:\n%s\n", i+1, job.Title, i+1, truncateText(job.Description, matchDescriptionLimit))
+	}
+	return fmt.Sprintf(`You are a job matching assistant.
+
+Analyze how well each of the %d jobs below matches the candidate's profile and return a JSON score for every job.
+
+Return JSON only with this exact structure:
+{
+  "matches": [
+    {
+      "job": job number,
+      "match_score": number from 0 to 100,
+      "strengths": ["strength1", "strength2"],
+      "weaknesses": ["weakness1"],
+      "short_summary": "one sentence summary"
+    }
+  ]
+}
+
+Rules:
+- Return exactly one entry per job, with "job" set to its number; score every job on its own
+- match_score: 90-100 = excellent fit, 70-89 = good fit, 50-69 = partial fit, below 50 = poor fit
+- Focus on the candidate's tech stack, target roles and stated preferences
+- strengths: what makes this a good match (max 3 items)
+- weaknesses: what might be missing (max 2 items)
+- short_summary: one sentence (max 20 words)
+
+%s%s`, len(jobs), describeProfile(profile), sb.String())
+}
+
+// extractPrompt asks for JobFacts as JSON. Changing it requires bumping
+// ExtractPromptVersion.
+func extractPrompt(job JobData) string {
+	return fmt.Sprintf(`You extract structured facts from job postings.
+
+Return JSON only with this exact structure:
+{
+%s
+}
+
+Rules:
+%s
+Job Title: %s
+
+Job Description:
+%s`, extractFields, extractRules, job.Title, truncateText(job.Description, extractDescriptionLimit))
+}
+
+// extractBatchPrompt asks for one JobFacts per job as a JSON array. Changing
+// it requires bumping ExtractBatchPromptVersion.
+func extractBatchPrompt(jobs []JobData) string {
+	var sb strings.Builder
+	for i, job := range jobs {
+		fmt.Fprintf(&sb, "\nJob %d Title: %s\nJob %d Description:\n%s\n", i+1, job.Title, i+1, truncateText(job.Description, extractDescriptionLimit))
+	}
+	return fmt.Sprintf(`You extract structured facts from job postings.
+
+Read each of the %d job postings below and return its facts as JSON.
+
+Return JSON only with this exact structure:
+{
+  "jobs": [
+    {
+      "job": job number,
+%s
+    }
+  ]
+}
+
+Rules:
+- Return exactly one entry per job, with "job" set to its number; read every job on its own
+%s%s`, len(jobs), indent(extractFields, "    "), extractRules, sb.String())
+}
+
+// extractFields and extractRules are shared by the single and batched
+// extraction prompts.
+const extractFields = `  "salary": {"value": {"min": number, "max": number, "currency": "ISO code", "period": "year" | "month" | "hour"}, "confidence": number},
+  "visa_sponsorship": {"value": boolean, "confidence": number},
+  "remote_regions": {"value": ["region"], "confidence": number},
+  "experience_years": {"value": number, "confidence": number},
+  "tech_stack": {"value": ["technology"], "confidence": number},
+  "employment_type": {"value": "full_time" | "part_time" | "contract" | "internship" | "temporary", "confidence": number}`
+
+const extractRules = `- Only report what the posting states; never guess from the company or the role
+- When the posting does not say, use confidence 0 and an empty or zero value
+- confidence from 0 to 1 (how clearly the posting states it)
+- salary: the advertised pay range; min equals max for a single figure
+- visa_sponsorship: true if sponsorship is offered, false if the posting rules it out
+- remote_regions: where remote hires must live, as short names like "US", "EU", "UK", "LATAM" or "Worldwide"
+- experience_years: the minimum years of experience required
+- tech_stack: languages, frameworks, databases and platforms the job uses (max 10 items)
+`
+
+// extractDescriptionLimit is how much of a description the extraction
+// prompts show.
+const extractDescriptionLimit = 3000
+
+// indent prefixes every line of text with prefix.
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// Output token limits: one answer, and one batch answer of n jobs.
+const answerMaxTokens = 500
+
+func matchBatchMaxTokens(n int) int {
+	return 100 + 200*n
+}
+
+func extractBatchMaxTokens(n int) int {
+	return 100 + answerMaxTokens*n
+}
+
+// parseClassification decodes a classification answered by model.
+func parseClassification(response, model string) (WebsiteClassification, error) {
+	var result WebsiteClassification
+	if err := json.Unmarshal([]byte(cleanJSON(response)), &result); err != nil {
+		return WebsiteClassification{}, fmt.Errorf("failed to parse classification: %w (response: %s)", err, response)
+	}
+	result.Model = model
+	return result, nil
+}
+
+// parseMatch decodes a match answered by model.
+func parseMatch(response, model string) (JobMatch, error) {
+	var result JobMatch
+	if err := json.Unmarshal([]byte(cleanJSON(response)), &result); err != nil {
+		return JobMatch{}, fmt.Errorf("failed to parse match result: %w (response: %s)", err, response)
+	}
+	result.Model, result.PromptVersion = model, MatchPromptVersion
+	return result, nil
+}
+
+// parseMatches decodes a MatchJobs answer for n jobs answered by model. Jobs
+// that are missing from the answer or scored out of range get an error.
+func parseMatches(response, model string, n int) (JobMatches, error) {
+	var answer struct {
+		Matches []struct {
+			Job int `json:"job"`
+			JobMatch
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal([]byte(cleanJSON(response)), &answer); err != nil {
+		return JobMatches{}, fmt.Errorf("failed to parse match results: %w (response: %s)", err, truncateText(response, 500))
+	}
+
+	result := newJobMatches(n)
+	answered := make([]bool, n)
+	for _, entry := range answer.Matches {
+		i := entry.Job - 1
+		if i < 0 || i >= n || answered[i] {
+			continue
+		}
+		answered[i] = true
+		if entry.MatchScore < 0 || entry.MatchScore > 100 {
+			result.Errs[i] = fmt.Errorf("match score %d out of range", entry.MatchScore)
+			continue
+		}
+		result.Matches[i] = entry.JobMatch
+		result.Matches[i].Model, result.Matches[i].PromptVersion = model, MatchBatchPromptVersion
+	}
+	for i := range answered {
+		if !answered[i] {
+			result.Errs[i] = fmt.Errorf("no answer for job %d of %d", i+1, n)
+		}
+	}
+	return result, nil
+}
+
+// describeProfile renders the candidate profile as prompt lines, skipping empty fields.
+func describeProfile(profile CandidateProfile) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Candidate's Tech Stack: %s\n", strings.Join(profile.TechStack, ", "))
+	if len(profile.Keywords) > 0 {
+		fmt.Fprintf(&sb, "Target roles/keywords: %s\n", strings.Join(profile.Keywords, ", "))
+	}
+	if len(profile.Seniority) > 0 {
+		fmt.Fprintf(&sb, "Preferred seniority: %s\n", strings.Join(profile.Seniority, ", "))
+	}
+	if profile.RemoteOnly {
+		sb.WriteString("Location: remote only\n")
+	}
+	if len(profile.Locations) > 0 {
+		fmt.Fprintf(&sb, "Acceptable locations: %s\n", strings.Join(profile.Locations, ", "))
+	}
+	if profile.SalaryFloor > 0 {
+		fmt.Fprintf(&sb, "Minimum salary: %d\n", profile.SalaryFloor)
+	}
+	if profile.Preferences != "" {
+		fmt.Fprintf(&sb, "Other preferences: %s\n", truncateText(profile.Preferences, 300))
+	}
+	return sb.String()
+}
+
+// truncateText limits text to maxLen characters
+func truncateText(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		return text
+	}
+	return text[:maxLen] + "..."
+}
+
+// cleanJSON cuts the JSON object out of an answer, dropping markdown code
+// fences and any text a chatty model put around it.
+func cleanJSON(s string) string {
+	start, end := strings.Index(s, "{"), strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return strings.TrimSpace(s)
+	}
+	return s[start : end+1]
+}
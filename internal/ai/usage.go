@@ -0,0 +1,156 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+)
+
+// Price is a model's list price in USD per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// defaultPrices are list prices of common hosted models. Models missing here
+// (local ones in particular) cost nothing; AI_PRICES adds or overrides entries.
+var defaultPrices = map[string]Price{
+	"gemini/gemini-1.5-flash": {Input: 0.075, Output: 0.30},
+	"gemini/gemini-1.5-pro":   {Input: 1.25, Output: 5.00},
+	"gemini/gemini-2.0-flash": {Input: 0.10, Output: 0.40},
+	"openai/gpt-4o-mini":      {Input: 0.15, Output: 0.60},
+	"openai/gpt-4o":           {Input: 2.50, Output: 10.00},
+}
+
+// pricesFromEnv merges AI_PRICES ("model=input:output,...", USD per million
+// tokens) over the defaults.
+func pricesFromEnv() map[string]Price {
+	prices := make(map[string]Price, len(defaultPrices))
+	for model, price := range defaultPrices {
+		prices[model] = price
+	}
+	for _, entry := range strings.Split(os.Getenv("AI_PRICES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		price, err := parsePrice(entry)
+		if err != nil {
+			fmt.Printf("WARNING: ignoring AI_PRICES entry %q: %v\n", entry, err)
+			continue
+		}
+		model, _, _ := strings.Cut(entry, "=")
+		prices[strings.TrimSpace(model)] = price
+	}
+	return prices
+}
+
+func parsePrice(entry string) (Price, error) {
+	_, rates, ok := strings.Cut(entry, "=")
+	if !ok {
+		return Price{}, fmt.Errorf("want model=input:output")
+	}
+	in, out, ok := strings.Cut(rates, ":")
+	if !ok {
+		return Price{}, fmt.Errorf("want model=input:output")
+	}
+	inRate, err := strconv.ParseFloat(strings.TrimSpace(in), 64)
+	if err != nil {
+		return Price{}, err
+	}
+	outRate, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+	if err != nil {
+		return Price{}, err
+	}
+	return Price{Input: inRate, Output: outRate}, nil
+}
+
+// Cost estimates the USD cost of usage at this price.
+func (p Price) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input + float64(u.OutputTokens)*p.Output) / 1e6
+}
+
+// UsageRecorder persists per-day AI usage. store.Store implements it.
+type UsageRecorder interface {
+	RecordAIUsage(ctx context.Context, model, operation string, failed bool, inputTokens, outputTokens int, costUSD float64) error
+}
+
+// Store is what NewClient persists to: cached answers and usage.
+type Store interface {
+	Cache
+	UsageRecorder
+}
+
+// MeteredClient records every request of the wrapped client, failed ones
+// included, with its tokens and estimated cost.
+type MeteredClient struct {
+	inner    Client
+	recorder UsageRecorder
+	price    Price
+}
+
+func NewMeteredClient(inner Client, recorder UsageRecorder, price Price) *MeteredClient {
+	return &MeteredClient{inner: inner, recorder: recorder, price: price}
+}
+
+// withUsage meters a real provider. The mock makes no requests.
+func withUsage(client Client, recorder UsageRecorder, prices map[string]Price) Client {
+	if recorder == nil {
+		return client
+	}
+	if _, ok := client.(*MockClient); ok {
+		return client
+	}
+	return NewMeteredClient(client, recorder, prices[client.Model()])
+}
+
+func (m *MeteredClient) Model() string {
+	return m.inner.Model()
+}
+
+func (m *MeteredClient) ClassifyWebsite(ctx context.Context, data WebsiteData) (WebsiteClassification, error) {
+	result, err := m.inner.ClassifyWebsite(ctx, data)
+	m.record(ctx, OpClassify, result.Usage, err)
+	return result, err
+}
+
+func (m *MeteredClient) MatchJob(ctx context.Context, job JobData, profile CandidateProfile) (JobMatch, error) {
+	result, err := m.inner.MatchJob(ctx, job, profile)
+	m.record(ctx, OpMatch, result.Usage, err)
+	return result, err
+}
+
+func (m *MeteredClient) MatchJobs(ctx context.Context, jobs []JobData, profile CandidateProfile) (JobMatches, error) {
+	result, err := m.inner.MatchJobs(ctx, jobs, profile)
+	m.record(ctx, OpMatch, result.Usage, err)
+	return result, err
+}
+
+func (m *MeteredClient) ExtractJobFacts(ctx context.Context, job JobData) (JobFacts, error) {
+	result, err := m.inner.ExtractJobFacts(ctx, job)
+	m.record(ctx, OpExtract, result.Usage, err)
+	return result, err
+}
+
+func (m *MeteredClient) ExtractJobsFacts(ctx context.Context, jobs []JobData) (JobFactsBatch, error) {
+	result, err := m.inner.ExtractJobsFacts(ctx, jobs)
+	m.record(ctx, OpExtract, result.Usage, err)
+	return result, err
+}
+
+func (m *MeteredClient) record(ctx context.Context, op string, usage Usage, callErr error) {
+	// The request was made even if ctx was cancelled meanwhile.
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	err := m.recorder.RecordAIUsage(writeCtx, m.inner.Model(), op, callErr != nil, usage.InputTokens, usage.OutputTokens, m.price.Cost(usage))
+	if err != nil {
+		observability.IncError(observability.ErrorStore, "ai_usage")
+		slog.Warn("ai usage record failed", "model", m.inner.Model(), "operation", op, "error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// maxUsageDays bounds how far back GET /ai/usage looks.
+const maxUsageDays = 366
+
+// handleAIUsage reports today's and this month's AI usage against the budget,
+// plus per-day, per-model usage for the last ?days= days (default 30).
+func (s *Server) handleAIUsage(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = min(parsed, maxUsageDays)
+	}
+
+	status, err := s.matcher.BudgetStatus(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load AI budget: "+err.Error())
+		return
+	}
+	since := time.Now().UTC().AddDate(0, 0, 1-days)
+	items, err := s.store.ListAIUsage(r.Context(), since)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load AI usage: "+err.Error())
+		return
+	}
+	if items == nil {
+		items = []store.AIUsage{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"budget":    status.Budget,
+		"today":     status.Today,
+		"month":     status.Month,
+		"exhausted": status.Exhausted,
+		"tight":     status.Tight,
+		"reason":    status.Reason,
+		"days":      days,
+		"items":     items,
+		"total":     len(items),
+	})
+}
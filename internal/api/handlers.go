@@ -4,9 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
@@ -17,11 +17,22 @@ import (
 	"github.com/baxromumarov/job-hunter/internal/urlutil"
 )
 
-// handleListJobs works as a mock for now since we don't have the full DB implementation for Jobs yet
+// handleListJobs returns the ranked job list of one profile (?profile=, default profile if omitted).
+// ?seniority= and ?role_family= take comma-separated values; ?max_experience= caps required years.
 func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
 	limit, offset := parsePagination(r, 20)
+	profile, ok := s.resolveProfile(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := parseJobFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid filter: "+err.Error())
+		return
+	}
 
-	jobs, total, activeTotal, err := s.store.GetJobs(r.Context(), limit, offset)
+	jobs, total, activeTotal, err := s.store.GetJobs(r.Context(), profile.ID, filter, limit, offset)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch jobs: "+err.Error())
 		return
@@ -32,6 +43,7 @@ func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
 	}
 	respondJSON(w, http.StatusOK, map[string]any{
 		"items":        jobs,
+		"profile":      profile.Name,
 		"limit":        limit,
 		"offset":       offset,
 		"total":        total,
@@ -58,6 +70,32 @@ func (s *Server) handleListSources(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func parseJobFilter(r *http.Request) (store.JobFilter, error) {
+	q := r.URL.Query()
+	filter := store.JobFilter{
+		Seniority:    splitQueryList(q.Get("seniority")),
+		RoleFamilies: splitQueryList(q.Get("role_family")),
+	}
+	if v := q.Get("max_experience"); v != "" {
+		years, err := strconv.Atoi(v)
+		if err != nil || years < 0 {
+			return filter, errors.New("max_experience must be a non-negative integer")
+		}
+		filter.MaxExperienceYears = years
+	}
+	return filter, nil
+}
+
+func splitQueryList(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 type AddSourceRequest struct {
 	URL        string `json:"url"`
 	SourceType string `json:"source_type"`
@@ -370,24 +408,32 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// History snapshots are written by the stats_snapshot schedule.
 	snapshot := observability.Snapshot()
-	if err := s.store.SaveStatsSnapshot(r.Context(), snapshot, sourcesTotal, jobsTotal, activeJobs); err != nil {
-		slog.Error("stats snapshot save failed", "error", err)
-	}
 	respondJSON(w, http.StatusOK, map[string]any{
-		"pages_crawled":     snapshot.PagesCrawled,
-		"jobs_discovered":   snapshot.JobsDiscovered,
-		"jobs_extracted":    snapshot.JobsExtracted,
-		"ai_calls":          snapshot.AICalls,
-		"errors_total":      snapshot.ErrorsTotal,
-		"crawl_avg_seconds": snapshot.CrawlSecondsAvg,
-		"urls_discovered":   snapshot.URLsDiscovered,
-		"sources_promoted":  snapshot.SourcesPromoted,
-		"ats_detected":      snapshot.ATSDetected,
-		"sources_zero_jobs": snapshot.SourcesZeroJobs,
-		"sources_total":     sourcesTotal,
-		"jobs_total":        jobsTotal,
-		"active_jobs":       activeJobs,
+		"pages_crawled":        snapshot.PagesCrawled,
+		"jobs_discovered":      snapshot.JobsDiscovered,
+		"jobs_extracted":       snapshot.JobsExtracted,
+		"ai_calls":             snapshot.AICalls,
+		"errors_total":         snapshot.ErrorsTotal,
+		"crawl_avg_seconds":    snapshot.CrawlSecondsAvg,
+		"urls_discovered":      snapshot.URLsDiscovered,
+		"sources_promoted":     snapshot.SourcesPromoted,
+		"ats_detected":         snapshot.ATSDetected,
+		"sources_zero_jobs":    snapshot.SourcesZeroJobs,
+		"jobs_unchanged":       snapshot.JobsUnchanged,
+		"jobs_rule_rejected":   snapshot.JobsRuleRejected,
+		"sources_processed":    snapshot.SourcesProcessed,
+		"sources_skipped":      snapshot.SourcesSkipped,
+		"sources_circuit_open": snapshot.SourcesCircuit,
+		"pipeline":             snapshot.Pipeline,
+		"leases":               snapshot.Leases,
+		"ai_providers":         s.matcher.ProviderHealth(),
+		"tasks":                snapshot.Tasks,
+		"ai_cache":             snapshot.AICache,
+		"sources_total":        sourcesTotal,
+		"jobs_total":           jobsTotal,
+		"active_jobs":          activeJobs,
 	})
 }
 
@@ -422,6 +468,30 @@ func (s *Server) handleStatsHistory(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleGetJobScore(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	profile, ok := s.resolveProfile(w, r)
+	if !ok {
+		return
+	}
+
+	breakdown, err := s.store.GetScoreBreakdown(r.Context(), profile.ID, jobID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load score: "+err.Error())
+		return
+	}
+	if breakdown == nil {
+		respondError(w, http.StatusNotFound, "No score breakdown for this job")
+		return
+	}
+	respondJSON(w, http.StatusOK, breakdown)
+}
+
 func (s *Server) handleApplyJob(w http.ResponseWriter, r *http.Request) {
 	jobIDStr := chi.URLParam(r, "id")
 	jobID, err := strconv.Atoi(jobIDStr)
@@ -430,8 +500,13 @@ func (s *Server) handleApplyJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.store.MarkJobApplied(r.Context(), jobID); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to mark job as applied: "+err.Error())
+	profile, ok := s.resolveProfile(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.store.MarkJobApplied(r.Context(), profile.ID, jobID); err != nil {
+		respondJobStateError(w, err, "Failed to mark job as applied")
 		return
 	}
 
@@ -446,8 +521,13 @@ func (s *Server) handleRejectJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.store.MarkJobRejected(r.Context(), jobID); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to mark job as not a match: "+err.Error())
+	profile, ok := s.resolveProfile(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.store.MarkJobRejected(r.Context(), profile.ID, jobID); err != nil {
+		respondJobStateError(w, err, "Failed to mark job as not a match")
 		return
 	}
 
@@ -462,10 +542,23 @@ func (s *Server) handleCloseJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.store.MarkJobClosed(r.Context(), jobID); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to mark job as closed: "+err.Error())
+	profile, ok := s.resolveProfile(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.store.MarkJobClosed(r.Context(), profile.ID, jobID); err != nil {
+		respondJobStateError(w, err, "Failed to mark job as closed")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]bool{"closed": true})
 }
+
+func respondJobStateError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, message+": "+err.Error())
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/baxromumarov/job-hunter/internal/core"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// resolveProfile looks up the profile named by the "profile" query parameter,
+// defaulting to the default profile. It writes the error response itself.
+func (s *Server) resolveProfile(w http.ResponseWriter, r *http.Request) (*store.Profile, bool) {
+	name := strings.TrimSpace(r.URL.Query().Get("profile"))
+	if name == "" {
+		name = store.DefaultProfileName
+	}
+	profile, err := s.store.GetProfile(r.Context(), name)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load profile: "+err.Error())
+		return nil, false
+	}
+	if profile == nil {
+		respondError(w, http.StatusNotFound, "Unknown profile")
+		return nil, false
+	}
+	return profile, true
+}
+
+func (s *Server) handleListProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.store.ListProfiles(r.Context(), false)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load profiles: "+err.Error())
+		return
+	}
+	if profiles == nil {
+		profiles = []store.Profile{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"items": profiles,
+		"total": len(profiles),
+	})
+}
+
+func (s *Server) handleCreateProfile(w http.ResponseWriter, r *http.Request) {
+	req := store.Profile{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	existing, err := s.store.GetProfile(r.Context(), strings.TrimSpace(req.Name))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load profile: "+err.Error())
+		return
+	}
+	if existing != nil {
+		respondError(w, http.StatusConflict, "Profile already exists")
+		return
+	}
+
+	s.saveProfile(w, r, req, http.StatusCreated)
+}
+
+func (s *Server) handleGetProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := s.store.GetProfile(r.Context(), profileNameParam(r))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load profile: "+err.Error())
+		return
+	}
+	if profile == nil {
+		respondError(w, http.StatusNotFound, "Unknown profile")
+		return
+	}
+	respondJSON(w, http.StatusOK, profile)
+}
+
+// handleUpdateProfile applies the request body on top of the stored profile,
+// so fields left out of the body keep their current values. Weighted and
+// negative terms in the body replace the stored ones instead of being merged.
+func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	name := profileNameParam(r)
+	existing, err := s.store.GetProfile(r.Context(), name)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load profile: "+err.Error())
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Unknown profile")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var patch struct {
+		Scoring map[string]json.RawMessage `json:"scoring"`
+	}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req := *existing
+	// encoding/json merges into non-nil maps.
+	if _, ok := patch.Scoring["keywords"]; ok {
+		req.Scoring.Keywords = nil
+	}
+	if _, ok := patch.Scoring["negative"]; ok {
+		req.Scoring.Negative = nil
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = name
+
+	s.saveProfile(w, r, req, http.StatusOK)
+}
+
+func (s *Server) saveProfile(w http.ResponseWriter, r *http.Request, req store.Profile, status int) {
+	profile, err := core.NormalizeProfile(req)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	saved, err := s.store.SaveProfile(r.Context(), profile)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save profile: "+err.Error())
+		return
+	}
+	respondJSON(w, status, saved)
+}
+
+// profileNameParam returns the {name} URL parameter, or the default profile
+// for the legacy /profile routes.
+func profileNameParam(r *http.Request) string {
+	if name := strings.TrimSpace(chi.URLParam(r, "name")); name != "" {
+		return name
+	}
+	return store.DefaultProfileName
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// handleRetentionPreview shows what the next retention pass would delete,
+// without deleting anything.
+func (s *Server) handleRetentionPreview(w http.ResponseWriter, r *http.Request) {
+	limit, _ := parsePagination(r, 50)
+
+	jobs, counts, err := s.ingestion.PreviewRetention(r.Context(), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to preview retention: "+err.Error())
+		return
+	}
+	if jobs == nil {
+		jobs = []store.ExpiredJob{}
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	policy := s.ingestion.RetentionPolicy()
+	respondJSON(w, http.StatusOK, map[string]any{
+		// 0 days keeps jobs in that state forever.
+		"policy_days": map[string]int{
+			store.RetentionUntouched: days(policy.Untouched),
+			store.RetentionClosed:    days(policy.Closed),
+			store.RetentionRejected:  days(policy.Rejected),
+		},
+		"counts": counts,
+		"items":  jobs,
+		"limit":  limit,
+		"total":  total,
+	})
+}
+
+func days(d time.Duration) int {
+	return int(d / (24 * time.Hour))
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/baxromumarov/job-hunter/internal/core"
+	"github.com/baxromumarov/job-hunter/internal/rules"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+func (s *Server) handleListRules(w http.ResponseWriter, r *http.Request) {
+	items, err := s.store.ListFilterRules(r.Context(), false)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load rules: "+err.Error())
+		return
+	}
+	if items == nil {
+		items = []store.FilterRule{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"items": items,
+		"total": len(items),
+	})
+}
+
+func (s *Server) handleCreateRule(w http.ResponseWriter, r *http.Request) {
+	req := store.FilterRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !normalizeRule(w, &req) {
+		return
+	}
+
+	created, err := s.store.CreateFilterRule(r.Context(), req)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save rule: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// handleUpdateRule applies the request body on top of the stored rule, so
+// fields left out of the body keep their current values.
+func (s *Server) handleUpdateRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rule ID")
+		return
+	}
+
+	existing, err := s.store.GetFilterRule(r.Context(), ruleID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load rule: "+err.Error())
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Unknown rule")
+		return
+	}
+
+	req := *existing
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.ID = ruleID
+	if !normalizeRule(w, &req) {
+		return
+	}
+
+	updated, err := s.store.UpdateFilterRule(r.Context(), req)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Unknown rule")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save rule: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rule ID")
+		return
+	}
+
+	err = s.store.DeleteFilterRule(r.Context(), ruleID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Unknown rule")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete rule: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// normalizeRule trims the rule and rejects invalid expressions with a 400
+// so broken rules never reach ingestion.
+func normalizeRule(w http.ResponseWriter, rule *store.FilterRule) bool {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Expression = strings.TrimSpace(rule.Expression)
+	rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+	if rule.Expression == "" {
+		respondError(w, http.StatusBadRequest, "Expression is required")
+		return false
+	}
+	if rule.Action != rules.ActionBoost {
+		rule.Boost = 0
+	}
+	if err := rules.Validate(core.RuleDefinition(*rule)); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rule: "+err.Error())
+		return false
+	}
+	return true
+}
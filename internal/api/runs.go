@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/baxromumarov/job-hunter/internal/core"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+type RunTriggerResponse struct {
+	RunID  int64  `json:"run_id"`
+	Status string `json:"status"`
+	// Coalesced is true when an active run for the same target was returned
+	// instead of starting a new one.
+	Coalesced bool `json:"coalesced"`
+}
+
+func (s *Server) handleTriggerSourceScrape(w http.ResponseWriter, r *http.Request) {
+	sourceID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid source ID")
+		return
+	}
+
+	run, created, err := s.ingestion.TriggerSourceScrape(r.Context(), sourceID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start scrape: "+err.Error())
+		return
+	}
+	respondRunTriggered(w, run, created)
+}
+
+func (s *Server) handleTriggerIngestion(w http.ResponseWriter, r *http.Request) {
+	run, created, err := s.ingestion.TriggerIngestion(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start ingestion: "+err.Error())
+		return
+	}
+	respondRunTriggered(w, run, created)
+}
+
+func (s *Server) handleTriggerDiscovery(w http.ResponseWriter, r *http.Request) {
+	run, created, err := s.discovery.TriggerRun(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start discovery: "+err.Error())
+		return
+	}
+	respondRunTriggered(w, run, created)
+}
+
+// handleTriggerRescore starts a rescore of stored jobs. The body holds the
+// filters of core.RescoreRequest and may be empty to rescore everything.
+func (s *Server) handleTriggerRescore(w http.ResponseWriter, r *http.Request) {
+	var req core.RescoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Limit < 0 || req.AIPerMinute < 0 {
+		respondError(w, http.StatusBadRequest, "limit and ai_per_minute must not be negative")
+		return
+	}
+
+	run, created, err := s.ingestion.TriggerRescore(r.Context(), req)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Active profile not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start rescore: "+err.Error())
+		return
+	}
+	respondRunTriggered(w, run, created)
+}
+
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	runID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid run ID")
+		return
+	}
+
+	run, err := s.store.GetRun(r.Context(), runID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load run: "+err.Error())
+		return
+	}
+	if run == nil {
+		respondError(w, http.StatusNotFound, "Run not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, run)
+}
+
+func respondRunTriggered(w http.ResponseWriter, run *store.Run, created bool) {
+	respondJSON(w, http.StatusAccepted, RunTriggerResponse{
+		RunID:     run.ID,
+		Status:    run.Status,
+		Coalesced: !created,
+	})
+}
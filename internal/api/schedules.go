@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// handleListSchedules lists the scheduled background jobs with their spec,
+// paused flag and last/next run bookkeeping.
+func (s *Server) handleListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := s.scheduler.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch schedules: "+err.Error())
+		return
+	}
+	if schedules == nil {
+		schedules = []store.Schedule{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"items": schedules,
+		"total": len(schedules),
+	})
+}
+
+func (s *Server) handlePauseSchedule(w http.ResponseWriter, r *http.Request) {
+	s.setSchedulePaused(w, r, true)
+}
+
+func (s *Server) handleResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	s.setSchedulePaused(w, r, false)
+}
+
+func (s *Server) setSchedulePaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	schedule, err := s.scheduler.SetPaused(r.Context(), chi.URLParam(r, "name"), paused)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Schedule not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update schedule: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, schedule)
+}
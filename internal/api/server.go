@@ -12,6 +12,7 @@ import (
 	"github.com/go-chi/cors"
 
 	"github.com/baxromumarov/job-hunter/internal/core"
+	"github.com/baxromumarov/job-hunter/internal/discovery"
 	"github.com/baxromumarov/job-hunter/internal/store"
 )
 
@@ -20,14 +21,20 @@ type Server struct {
 	store      *store.Store
 	classifier *core.ClassifierService
 	matcher    *core.MatcherService
+	ingestion  *core.IngestionService
+	discovery  *discovery.Engine
+	scheduler  *core.Scheduler
 }
 
-func NewServer(store *store.Store, classifier *core.ClassifierService, matcher *core.MatcherService) *Server {
+func NewServer(store *store.Store, classifier *core.ClassifierService, matcher *core.MatcherService, ingestion *core.IngestionService, discovery *discovery.Engine, scheduler *core.Scheduler) *Server {
 	s := &Server{
 		router:     chi.NewRouter(),
 		store:      store,
 		classifier: classifier,
 		matcher:    matcher,
+		ingestion:  ingestion,
+		discovery:  discovery,
+		scheduler:  scheduler,
 	}
 
 	s.setupRoutes()
@@ -46,12 +53,36 @@ func (s *Server) setupRoutes() {
 	s.router.Get("/health", s.handleHealth)
 	s.router.Get("/stats", s.handleStats)
 	s.router.Get("/stats/history", s.handleStatsHistory)
+	s.router.Get("/ai/usage", s.handleAIUsage)
 	s.router.Get("/jobs", s.handleListJobs)
+	s.router.Post("/jobs/rescore", s.handleTriggerRescore)
+	s.router.Get("/jobs/retention/preview", s.handleRetentionPreview)
+	s.router.Get("/jobs/{id}/score", s.handleGetJobScore)
 	s.router.Post("/jobs/{id}/apply", s.handleApplyJob)
 	s.router.Post("/jobs/{id}/reject", s.handleRejectJob)
 	s.router.Post("/jobs/{id}/close", s.handleCloseJob)
 	s.router.Get("/sources", s.handleListSources)
 	s.router.Post("/sources", s.handleAddSource)
+	s.router.Put("/sources/{id}/schedule", s.handleUpdateSourceSchedule)
+	s.router.Post("/sources/{id}/scrape", s.handleTriggerSourceScrape)
+	s.router.Post("/ingestion/runs", s.handleTriggerIngestion)
+	s.router.Post("/discovery/runs", s.handleTriggerDiscovery)
+	s.router.Get("/runs/{id}", s.handleGetRun)
+	s.router.Get("/schedules", s.handleListSchedules)
+	s.router.Post("/schedules/{name}/pause", s.handlePauseSchedule)
+	s.router.Post("/schedules/{name}/resume", s.handleResumeSchedule)
+	s.router.Get("/tasks", s.handleListTasks)
+	s.router.Post("/tasks/{id}/requeue", s.handleRequeueTask)
+	s.router.Get("/profile", s.handleGetProfile)
+	s.router.Put("/profile", s.handleUpdateProfile)
+	s.router.Get("/profiles", s.handleListProfiles)
+	s.router.Post("/profiles", s.handleCreateProfile)
+	s.router.Get("/profiles/{name}", s.handleGetProfile)
+	s.router.Put("/profiles/{name}", s.handleUpdateProfile)
+	s.router.Get("/rules", s.handleListRules)
+	s.router.Post("/rules", s.handleCreateRule)
+	s.router.Put("/rules/{id}", s.handleUpdateRule)
+	s.router.Delete("/rules/{id}", s.handleDeleteRule)
 
 	// Serve static files
 	workDir, _ := os.Getwd()
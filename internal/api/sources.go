@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+type SourceScheduleRequest struct {
+	// IntervalMinutes pins the scrape interval; 0 returns to adaptive scheduling.
+	IntervalMinutes int  `json:"interval_minutes"`
+	ScrapeNow       bool `json:"scrape_now"`
+}
+
+func (s *Server) handleUpdateSourceSchedule(w http.ResponseWriter, r *http.Request) {
+	sourceID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid source ID")
+		return
+	}
+
+	var req SourceScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.IntervalMinutes < 0 {
+		respondError(w, http.StatusBadRequest, "interval_minutes must not be negative")
+		return
+	}
+
+	err = s.store.SetSourceIntervalOverride(r.Context(), sourceID, req.IntervalMinutes, req.ScrapeNow)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update schedule: "+err.Error())
+		return
+	}
+
+	src, err := s.store.GetSource(r.Context(), sourceID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load source: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, src)
+}
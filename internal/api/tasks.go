@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// handleListTasks lists queued work, newest first. ?status=dead shows the
+// dead-lettered tasks; ?type narrows to one task type.
+func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r, 20)
+	status := r.URL.Query().Get("status")
+	taskType := r.URL.Query().Get("type")
+	if status != "" && !slices.Contains([]string{store.TaskPending, store.TaskRunning, store.TaskDone, store.TaskDead}, status) {
+		respondError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+	if taskType != "" && !slices.Contains(store.TaskTypes, taskType) {
+		respondError(w, http.StatusBadRequest, "Invalid task type")
+		return
+	}
+
+	tasks, total, err := s.store.ListTasks(r.Context(), status, taskType, limit, offset)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tasks: "+err.Error())
+		return
+	}
+	if tasks == nil {
+		tasks = []store.Task{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"items":  tasks,
+		"limit":  limit,
+		"offset": offset,
+		"total":  total,
+	})
+}
+
+func (s *Server) handleRequeueTask(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	task, err := s.store.RequeueTask(r.Context(), taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Dead task not found")
+		return
+	}
+	if errors.Is(err, store.ErrTaskActive) {
+		respondError(w, http.StatusConflict, "Failed to requeue task: "+err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to requeue task: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, task)
+}
@@ -3,9 +3,13 @@ package core
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
 
 	"github.com/baxromumarov/job-hunter/internal/ai"
 	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
 )
 
 type ClassifierService struct {
@@ -16,6 +20,14 @@ func NewClassifierService(aiClient ai.Client) *ClassifierService {
 	return &ClassifierService{aiClient: aiClient}
 }
 
+// Enabled reports whether a real AI provider backs the classifier. The mock's
+// answers must not overturn the heuristics.
+func (s *ClassifierService) Enabled() bool {
+	return s.aiClient.Model() != ai.MockModel
+}
+
+// Classify asks the AI whether a page is a tech job source. The result names
+// the model that answered.
 func (s *ClassifierService) Classify(ctx context.Context, url, title, meta, text string) (*ai.WebsiteClassification, error) {
 	data := ai.WebsiteData{
 		URL:             url,
@@ -35,10 +47,41 @@ func (s *ClassifierService) Classify(ctx context.Context, url, title, meta, text
 
 type MatcherService struct {
 	aiClient ai.Client
+	budget   *aiBudgetGuard
+	// batchSize caps the jobs sent in one MatchJobs request.
+	batchSize int
+}
+
+// NewMatcherService creates a matcher. Unless st is nil, matching stops with
+// ErrAIBudgetExhausted once the AI budget recorded in st is spent.
+func NewMatcherService(aiClient ai.Client, st *store.Store) *MatcherService {
+	s := &MatcherService{
+		aiClient:  aiClient,
+		batchSize: max(intFromEnv("AI_MATCH_BATCH_SIZE", 5), 1),
+	}
+	if st != nil {
+		s.budget = newAIBudgetGuard(st)
+	}
+	return s
+}
+
+// ProviderHealth reports the health of the AI providers behind the matcher.
+func (s *MatcherService) ProviderHealth() map[string]ai.ProviderStatus {
+	return ai.ProviderHealth(s.aiClient)
+}
+
+// BudgetStatus reports AI usage against the configured budget.
+func (s *MatcherService) BudgetStatus(ctx context.Context) (AIBudgetStatus, error) {
+	if s.budget == nil {
+		return AIBudgetStatus{}, fmt.Errorf("AI usage is not recorded")
+	}
+	return s.budget.Status(ctx)
 }
 
-func NewMatcherService(aiClient ai.Client) *MatcherService {
-	return &MatcherService{aiClient: aiClient}
+// Model names the preferred AI model for match scores. With a fallback chain
+// some scores come from other models; a stale-only rescore moves them back.
+func (s *MatcherService) Model() string {
+	return s.aiClient.Model()
 }
 
 func (s *MatcherService) Match(ctx context.Context, jobTitle, jobDesc string, profile ai.CandidateProfile) (*ai.JobMatch, error) {
@@ -47,6 +90,10 @@ func (s *MatcherService) Match(ctx context.Context, jobTitle, jobDesc string, pr
 		Description: jobDesc,
 	}
 
+	if s.budget.exhausted(ctx) {
+		return nil, ErrAIBudgetExhausted
+	}
+
 	observability.IncAICall("matcher")
 	result, err := s.aiClient.MatchJob(ctx, jobData, profile)
 	if err != nil {
@@ -55,3 +102,135 @@ func (s *MatcherService) Match(ctx context.Context, jobTitle, jobDesc string, pr
 
 	return &result, nil
 }
+
+// ExtractFacts reads the structured facts of several postings in a single
+// ExtractJobsFacts request. It shares the matcher's client and AI budget.
+func (s *MatcherService) ExtractFacts(ctx context.Context, jobs []ai.JobData) (ai.JobFactsBatch, error) {
+	if s.budget.exhausted(ctx) {
+		return ai.JobFactsBatch{}, ErrAIBudgetExhausted
+	}
+
+	observability.IncAICall("extractor")
+	result, err := s.aiClient.ExtractJobsFacts(ctx, jobs)
+	if err != nil {
+		return result, fmt.Errorf("extraction failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// matchBatchParallel caps the AI calls MatchBatch keeps in flight.
+const matchBatchParallel = 4
+
+// MatchRequest is one job/profile pair scored by MatchBatch. Requests with the
+// same Group and ProfileID share MatchJobs requests; the pipeline groups by
+// source so one request covers similar postings.
+type MatchRequest struct {
+	Title       string
+	Description string
+	Profile     ai.CandidateProfile
+	ProfileID   int
+	Group       int
+}
+
+type MatchResult struct {
+	Match *ai.JobMatch
+	Err   error
+}
+
+// MatchBatch scores a batch of job/profile pairs, sending up to batchSize
+// jobs of a group per AI request. Results are indexed like reqs; a failed
+// pair only fails its own result.
+func (s *MatcherService) MatchBatch(ctx context.Context, reqs []MatchRequest) []MatchResult {
+	results := make([]MatchResult, len(reqs))
+	sem := make(chan struct{}, matchBatchParallel)
+	var wg sync.WaitGroup
+	for _, chunk := range s.matchChunks(reqs) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for _, i := range chunk {
+				results[i].Err = ctx.Err()
+			}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.matchChunk(ctx, reqs, chunk, results)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// matchChunks groups request indexes by group and profile, in order of first
+// appearance, and splits each group into chunks of at most batchSize.
+func (s *MatcherService) matchChunks(reqs []MatchRequest) [][]int {
+	type key struct{ group, profile int }
+	var (
+		order  []key
+		groups = make(map[key][]int)
+	)
+	for i, req := range reqs {
+		k := key{req.Group, req.ProfileID}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+	var chunks [][]int
+	for _, k := range order {
+		for chunk := range slices.Chunk(groups[k], s.batchSize) {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// matchChunk scores one chunk in a single MatchJobs request. Jobs the answer
+// left out, and all of them when the answer could not be used, are retried
+// one by one. When every provider is unavailable the chunk fails instead.
+func (s *MatcherService) matchChunk(ctx context.Context, reqs []MatchRequest, chunk []int, results []MatchResult) {
+	if len(chunk) == 1 {
+		req := reqs[chunk[0]]
+		match, err := s.Match(ctx, req.Title, req.Description, req.Profile)
+		results[chunk[0]] = MatchResult{Match: match, Err: err}
+		return
+	}
+	if s.budget.exhausted(ctx) {
+		for _, i := range chunk {
+			results[i].Err = ErrAIBudgetExhausted
+		}
+		return
+	}
+
+	jobs := make([]ai.JobData, len(chunk))
+	for j, i := range chunk {
+		jobs[j] = ai.JobData{Title: reqs[i].Title, Description: reqs[i].Description}
+	}
+	observability.IncAICall("matcher")
+	answer, err := s.aiClient.MatchJobs(ctx, jobs, reqs[chunk[0]].Profile)
+	if err != nil && (ctx.Err() != nil || ai.Retryable(err)) {
+		for _, i := range chunk {
+			results[i].Err = fmt.Errorf("matching failed: %w", err)
+		}
+		return
+	}
+	if err != nil {
+		slog.Warn("ai batch match failed, matching one by one", "jobs", len(chunk), "error", err)
+	}
+
+	for j, i := range chunk {
+		if err == nil && answer.Errs[j] == nil {
+			results[i].Match = &answer.Matches[j]
+			continue
+		}
+		if err == nil {
+			slog.Warn("ai batch match incomplete, matching job alone", "title", reqs[i].Title, "error", answer.Errs[j])
+		}
+		match, matchErr := s.Match(ctx, reqs[i].Title, reqs[i].Description, reqs[i].Profile)
+		results[i] = MatchResult{Match: match, Err: matchErr}
+	}
+}
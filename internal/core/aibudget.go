@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// ErrAIBudgetExhausted is returned by MatcherService.Match once a daily or
+// monthly AI budget is spent. Jobs are then scored by keywords alone.
+var ErrAIBudgetExhausted = errors.New("AI budget exhausted")
+
+// budgetRefresh is how long a budget check is reused. Usage recorded by
+// other replicas counts from the next check.
+const budgetRefresh = 30 * time.Second
+
+// AIBudget caps AI usage across all providers. Zero fields are unlimited.
+// Days and months are UTC.
+type AIBudget struct {
+	DailyUSD      float64 `json:"daily_usd"`
+	MonthlyUSD    float64 `json:"monthly_usd"`
+	DailyRequests int     `json:"daily_requests"`
+}
+
+func aiBudgetFromEnv() AIBudget {
+	return AIBudget{
+		DailyUSD:      floatFromEnv("AI_DAILY_BUDGET_USD", 0),
+		MonthlyUSD:    floatFromEnv("AI_MONTHLY_BUDGET_USD", 0),
+		DailyRequests: max(intFromEnv("AI_DAILY_REQUEST_LIMIT", 0), 0),
+	}
+}
+
+func (b AIBudget) unlimited() bool {
+	return b.DailyUSD <= 0 && b.MonthlyUSD <= 0 && b.DailyRequests <= 0
+}
+
+// AIBudgetStatus is the usage so far against the budget.
+type AIBudgetStatus struct {
+	Budget    AIBudget            `json:"budget"`
+	Today     store.AIUsageTotals `json:"today"`
+	Month     store.AIUsageTotals `json:"month"`
+	Exhausted bool                `json:"exhausted"`
+	// Tight is set once tightBudgetShare of a budget is used.
+	Tight  bool   `json:"tight"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// tightBudgetShare is the share of a budget after which optional AI calls
+// stop, keeping the rest for match scores.
+const tightBudgetShare = 0.8
+
+// aiBudgetGuard tells the matcher whether AI calls are still within budget.
+type aiBudgetGuard struct {
+	store  *store.Store
+	budget AIBudget
+
+	mu          sync.Mutex
+	checkedAt   time.Time
+	spent       bool
+	nearlySpent bool
+}
+
+func newAIBudgetGuard(st *store.Store) *aiBudgetGuard {
+	return &aiBudgetGuard{store: st, budget: aiBudgetFromEnv()}
+}
+
+// Status loads today's and this month's usage and compares it to the budget.
+func (g *aiBudgetGuard) Status(ctx context.Context) (AIBudgetStatus, error) {
+	status := AIBudgetStatus{Budget: g.budget}
+	now := time.Now().UTC()
+	var err error
+	if status.Today, err = g.store.GetAIUsageTotals(ctx, now); err != nil {
+		return status, err
+	}
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if status.Month, err = g.store.GetAIUsageTotals(ctx, monthStart); err != nil {
+		return status, err
+	}
+
+	b := g.budget
+	switch {
+	case b.DailyRequests > 0 && status.Today.Requests >= b.DailyRequests:
+		status.Reason = fmt.Sprintf("daily request limit of %d reached", b.DailyRequests)
+	case b.DailyUSD > 0 && status.Today.CostUSD >= b.DailyUSD:
+		status.Reason = fmt.Sprintf("daily budget of $%.2f spent", b.DailyUSD)
+	case b.MonthlyUSD > 0 && status.Month.CostUSD >= b.MonthlyUSD:
+		status.Reason = fmt.Sprintf("monthly budget of $%.2f spent", b.MonthlyUSD)
+	}
+	status.Exhausted = status.Reason != ""
+	status.Tight = status.Exhausted ||
+		b.DailyRequests > 0 && float64(status.Today.Requests) >= tightBudgetShare*float64(b.DailyRequests) ||
+		b.DailyUSD > 0 && status.Today.CostUSD >= tightBudgetShare*b.DailyUSD ||
+		b.MonthlyUSD > 0 && status.Month.CostUSD >= tightBudgetShare*b.MonthlyUSD
+	return status, nil
+}
+
+// exhausted reports whether the budget is spent, reusing the last check for
+// budgetRefresh. When usage cannot be loaded AI calls are allowed.
+func (g *aiBudgetGuard) exhausted(ctx context.Context) bool {
+	spent, _ := g.check(ctx)
+	return spent
+}
+
+// tight reports whether the budget is spent or nearly so. Optional AI calls
+// such as fact extraction leave the rest to match scores.
+func (g *aiBudgetGuard) tight(ctx context.Context) bool {
+	_, tight := g.check(ctx)
+	return tight
+}
+
+func (g *aiBudgetGuard) check(ctx context.Context) (spent, tight bool) {
+	if g == nil || g.budget.unlimited() {
+		return false, false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Since(g.checkedAt) < budgetRefresh {
+		return g.spent, g.nearlySpent
+	}
+
+	status, err := g.Status(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			observability.IncError(observability.ErrorStore, "ai_budget")
+			slog.Error("ai budget check failed", "error", err)
+		}
+		return g.spent, g.nearlySpent
+	}
+	if status.Exhausted != g.spent {
+		if status.Exhausted {
+			slog.Warn("ai budget exhausted, scoring by keywords only", "reason", status.Reason)
+		} else {
+			slog.Info("ai budget available again")
+		}
+	}
+	g.checkedAt, g.spent, g.nearlySpent = time.Now(), status.Exhausted, status.Tight
+	return g.spent, g.nearlySpent
+}
+
+func floatFromEnv(name string, fallback float64) float64 {
+	val := strings.TrimSpace(os.Getenv(name))
+	if val == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil || f < 0 {
+		return fallback
+	}
+	return f
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// circuitBreaker stops scraping sources, and whole hosts, that keep failing.
+// A circuit opens after Threshold consecutive failed scrapes and stays open
+// for a cooldown that doubles with every trip in a row; then one probe scrape
+// decides whether it closes again.
+type circuitBreaker struct {
+	source store.CircuitPolicy
+	host   store.CircuitPolicy
+}
+
+func circuitBreakerFromEnv() circuitBreaker {
+	cooldown := time.Duration(max(intFromEnv("CIRCUIT_COOLDOWN_MINUTES", 30), 1)) * time.Minute
+	maxCooldown := max(time.Duration(intFromEnv("CIRCUIT_MAX_COOLDOWN_MINUTES", 1440))*time.Minute, cooldown)
+	return circuitBreaker{
+		source: store.CircuitPolicy{
+			Threshold:   max(intFromEnv("CIRCUIT_SOURCE_THRESHOLD", 5), 1),
+			Cooldown:    cooldown,
+			MaxCooldown: maxCooldown,
+		},
+		host: store.CircuitPolicy{
+			Threshold:   max(intFromEnv("CIRCUIT_HOST_THRESHOLD", 10), 1),
+			Cooldown:    cooldown,
+			MaxCooldown: maxCooldown,
+		},
+	}
+}
+
+// circuitAllows reports whether src may be scraped now. When it may not,
+// retryAt is when its circuits are worth asking again. The host circuit is
+// asked first; a probe one circuit granted is handed back when the next one
+// refuses, so it is not left half-open with nobody probing. Breaker lookups
+// that fail let the scrape through.
+func (s *IngestionService) circuitAllows(ctx context.Context, src store.Source) (bool, time.Time) {
+	circuits := []struct{ scope, key string }{
+		{store.CircuitScopeHost, store.HostCircuitKey(src.Host)},
+		{store.CircuitScopeSource, store.SourceCircuitKey(src.ID)},
+	}
+	var probes []int
+	for i, c := range circuits {
+		if c.key == "" {
+			continue
+		}
+		allowed, probe, retryAt, err := s.store.AcquireCircuit(ctx, c.scope, c.key)
+		if err != nil {
+			observability.IncError(observability.ErrorStore, "ingestion")
+			slog.Error("ingestion circuit check failed", "scope", c.scope, "key", c.key, "error", err)
+			continue
+		}
+		if probe {
+			probes = append(probes, i)
+		}
+		if !allowed {
+			for _, p := range probes {
+				if err := s.store.ReleaseCircuitProbe(ctx, circuits[p].scope, circuits[p].key); err != nil {
+					observability.IncError(observability.ErrorStore, "ingestion")
+					slog.Error("ingestion circuit update failed", "scope", circuits[p].scope, "key", circuits[p].key, "error", err)
+				}
+			}
+			return false, retryAt
+		}
+	}
+	return true, time.Time{}
+}
+
+// recordCircuits feeds a scrape result into the source and host circuits.
+func (s *IngestionService) recordCircuits(ctx context.Context, src store.Source, scrapeErr error) {
+	circuits := []struct {
+		scope, key string
+		policy     store.CircuitPolicy
+	}{
+		{store.CircuitScopeSource, store.SourceCircuitKey(src.ID), s.breaker.source},
+		{store.CircuitScopeHost, store.HostCircuitKey(src.Host), s.breaker.host},
+	}
+	for _, c := range circuits {
+		if c.key == "" {
+			continue
+		}
+		if scrapeErr == nil {
+			if err := s.store.RecordCircuitSuccess(ctx, c.scope, c.key); err != nil {
+				observability.IncError(observability.ErrorStore, "ingestion")
+				slog.Error("ingestion circuit update failed", "scope", c.scope, "key", c.key, "error", err)
+			}
+			continue
+		}
+
+		circuit, err := s.store.RecordCircuitFailure(ctx, c.scope, c.key, scrapeErr.Error(), c.policy)
+		if err != nil {
+			observability.IncError(observability.ErrorStore, "ingestion")
+			slog.Error("ingestion circuit update failed", "scope", c.scope, "key", c.key, "error", err)
+			continue
+		}
+		if circuit.State == store.CircuitOpen && circuit.Failures >= c.policy.Threshold {
+			slog.Warn("ingestion circuit open",
+				"scope", c.scope,
+				"key", c.key,
+				"failures", circuit.Failures,
+				"retry_at", circuit.RetryAt,
+			)
+		}
+	}
+}
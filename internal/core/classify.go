@@ -0,0 +1,161 @@
+package core
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+const (
+	SeniorityIntern    = "intern"
+	SeniorityJunior    = "junior"
+	SeniorityMid       = "mid"
+	SenioritySenior    = "senior"
+	SeniorityStaff     = "staff"
+	SeniorityPrincipal = "principal"
+
+	RoleBackend    = "backend"
+	RoleFrontend   = "frontend"
+	RoleSRE        = "sre"
+	RoleData       = "data"
+	RoleManagement = "management"
+)
+
+// Seniorities lists the levels from least to most senior.
+var Seniorities = []string{SeniorityIntern, SeniorityJunior, SeniorityMid, SenioritySenior, SeniorityStaff, SeniorityPrincipal}
+
+// RoleFamilies lists the role families the classifier can assign.
+var RoleFamilies = []string{RoleBackend, RoleFrontend, RoleSRE, RoleData, RoleManagement}
+
+type termGroup struct {
+	label string
+	terms []string
+}
+
+// seniorityTerms is checked in order, so "senior staff engineer" is staff and
+// "principal lead" is principal.
+var seniorityTerms = []termGroup{
+	{SeniorityIntern, []string{"intern", "internship", "trainee", "working student"}},
+	{SeniorityJunior, []string{"junior", "jr", "jr.", "entry level", "entry-level", "graduate", "new grad"}},
+	{SeniorityPrincipal, []string{"principal", "distinguished", "fellow", "director", "head of", "vp"}},
+	{SeniorityStaff, []string{"staff", "architect"}},
+	{SenioritySenior, []string{"senior", "sr", "sr.", "lead", "iii"}},
+	{SeniorityMid, []string{"mid", "mid-level", "intermediate", "ii"}},
+}
+
+// roleTerms is checked in order against the title; management wins over
+// everything since "Engineering Manager, Backend" is a management role.
+var roleTerms = []termGroup{
+	{RoleManagement, []string{"engineering manager", "manager", "director", "head of", "vp", "cto"}},
+	{RoleSRE, []string{"sre", "site reliability", "devops", "platform engineer", "infrastructure", "reliability"}},
+	{RoleBackend, []string{"backend", "back-end", "back end", "golang", "go developer", "go engineer", "api", "server", "distributed systems"}},
+	{RoleFrontend, []string{"frontend", "front-end", "front end", "react", "vue", "angular", "ui engineer", "web developer"}},
+	{RoleData, []string{"data engineer", "data scientist", "data", "machine learning", "ml engineer", "analytics"}},
+}
+
+var experiencePattern = regexp.MustCompile(`(?i)(\d{1,2})\s*(?:\+|plus)?\s*(?:(?:-|–|to)\s*\d{1,2}\s*\+?\s*)?years?(?:'|’)?(?:\s+of)?(?:\s+[\w/+#.-]+){0,3}?\s+(?:experience|exp\b)`)
+
+// JobClass is what the classifier derives from a posting. Empty or zero
+// fields mean the posting gave no usable signal.
+type JobClass struct {
+	Seniority       string
+	ExperienceYears int
+	RoleFamily      string
+}
+
+// classifyJob derives seniority, required experience and role family. Title
+// terms win; seniority falls back to the experience requirement and the role
+// family to the most frequent family in the description.
+func classifyJob(title, description string) JobClass {
+	class := JobClass{
+		Seniority:       firstGroup(seniorityTerms, title),
+		ExperienceYears: experienceYears(description),
+		RoleFamily:      firstGroup(roleTerms, title),
+	}
+	if class.Seniority == "" {
+		class.Seniority = seniorityFromYears(class.ExperienceYears)
+	}
+	if class.RoleFamily == "" {
+		class.RoleFamily = dominantGroup(roleTerms, description)
+	}
+	return class
+}
+
+func firstGroup(groups []termGroup, text string) string {
+	for _, g := range groups {
+		for _, term := range g.terms {
+			if containsTerm(text, term) {
+				return g.label
+			}
+		}
+	}
+	return ""
+}
+
+// dominantGroup returns the group with the most distinct matching terms,
+// requiring at least two so a single passing mention does not decide it.
+func dominantGroup(groups []termGroup, text string) string {
+	best, bestHits := "", 1
+	for _, g := range groups {
+		hits := 0
+		for _, term := range g.terms {
+			if containsTerm(text, term) {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = g.label, hits
+		}
+	}
+	return best
+}
+
+// experienceYears returns the largest "N+ years of experience" requirement,
+// ignoring implausible numbers such as company age.
+func experienceYears(text string) int {
+	years := 0
+	for _, m := range experiencePattern.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n > 20 {
+			continue
+		}
+		years = max(years, n)
+	}
+	return years
+}
+
+func seniorityFromYears(years int) string {
+	switch {
+	case years >= 5:
+		return SenioritySenior
+	case years >= 2:
+		return SeniorityMid
+	case years >= 1:
+		return SeniorityJunior
+	default:
+		return ""
+	}
+}
+
+// classFits applies the profile's seniority, role family and experience
+// filters. Unknown values pass so unclassified postings are not lost.
+func classFits(profile store.Profile, class JobClass) bool {
+	return classMismatch(profile, class) == ""
+}
+
+// classMismatch explains which of the profile's class filters the posting
+// fails, or returns "" when it fits.
+func classMismatch(profile store.Profile, class JobClass) string {
+	if class.Seniority != "" && len(profile.Seniority) > 0 && !slices.Contains(profile.Seniority, class.Seniority) {
+		return "seniority " + class.Seniority + " not wanted"
+	}
+	if class.RoleFamily != "" && len(profile.RoleFamilies) > 0 && !slices.Contains(profile.RoleFamilies, class.RoleFamily) {
+		return "role family " + class.RoleFamily + " not wanted"
+	}
+	if profile.MaxExperienceYears > 0 && class.ExperienceYears > profile.MaxExperienceYears {
+		return "requires " + strconv.Itoa(class.ExperienceYears) + "+ years"
+	}
+	return ""
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/ai"
+	"github.com/baxromumarov/job-hunter/internal/scraper"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// DryRunRequest describes a scrape that is run and scored but never stored.
+type DryRunRequest struct {
+	URL string
+	// Scraper forces a scraper kind; empty picks one by host like ingestion.
+	Scraper string
+	// SourceType is what filter rules see as source_type; empty derives it
+	// from the scraper (company_page for generic, job_board otherwise).
+	SourceType string
+	// Since is how far back scrapers are asked for postings; zero uses the
+	// ingestion window. The max-age rule still decides what is too old.
+	Since time.Time
+	// Relaxed calls FetchJobsRelaxed right away instead of only as the
+	// fallback for a company page without jobs.
+	Relaxed bool
+	// Profile limits scoring to one active profile; empty means all of them.
+	Profile string
+	// SkipAI scores with keywords only.
+	SkipAI bool
+}
+
+// DryRunResult is every posting a dry run extracted and what the pipeline
+// would have done with it.
+type DryRunResult struct {
+	URL          string      `json:"url"`
+	Scraper      string      `json:"scraper"`
+	SourceType   string      `json:"source_type"`
+	Relaxed      bool        `json:"relaxed"`
+	Since        time.Time   `json:"since"`
+	RulesVersion string      `json:"rules_version"`
+	Jobs         []DryRunJob `json:"jobs"`
+}
+
+// DryRunJob is one extracted posting. Passed means at least one profile
+// would have stored it; Reason says why it was dropped or what it matched.
+type DryRunJob struct {
+	URL             string          `json:"url"`
+	Title           string          `json:"title"`
+	Company         string          `json:"company"`
+	Location        string          `json:"location"`
+	PostedAt        *time.Time      `json:"posted_at,omitempty"`
+	Seniority       string          `json:"seniority,omitempty"`
+	RoleFamily      string          `json:"role_family,omitempty"`
+	ExperienceYears int             `json:"experience_years,omitempty"`
+	Facts           *store.JobFacts `json:"facts,omitempty"`
+	Passed          bool            `json:"passed"`
+	Reason          string          `json:"reason"`
+	Profiles        []DryRunScore   `json:"profiles,omitempty"`
+}
+
+// DryRunScore is how one profile scored a posting and the rule decision it
+// got.
+type DryRunScore struct {
+	Profile   string              `json:"profile"`
+	Passed    bool                `json:"passed"`
+	Reason    string              `json:"reason"`
+	Rule      *store.RuleDecision `json:"rule,omitempty"`
+	RuleScore int                 `json:"rule_score"`
+	AIScore   *int                `json:"ai_score,omitempty"`
+	Score     int                 `json:"score"`
+	Summary   string              `json:"summary,omitempty"`
+}
+
+// DryRun scrapes req.URL and runs every posting through normalization,
+// classification, filter rules, profile filters and scoring, exactly like
+// ingestion, but writes nothing: no jobs, source state, circuits or tasks.
+// Stored scores are ignored so every posting is scored from scratch. It
+// returns store.ErrNotFound when req names a profile that is not active.
+func (s *IngestionService) DryRun(ctx context.Context, req DryRunRequest) (*DryRunResult, error) {
+	kind := req.Scraper
+	if kind == "" {
+		kind = scraperKind(req.URL)
+	}
+	scr, err := newScraper(kind, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	sourceType := req.SourceType
+	if sourceType == "" {
+		sourceType = "job_board"
+		if kind == ScraperGeneric {
+			sourceType = "company_page"
+		}
+	}
+
+	cycle, err := s.loadCycle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Profile != "" {
+		cycle.profiles = slices.DeleteFunc(cycle.profiles, func(p store.Profile) bool { return p.Name != req.Profile })
+		if len(cycle.profiles) == 0 {
+			return nil, store.ErrNotFound
+		}
+	}
+	if !req.Since.IsZero() {
+		cycle.since = req.Since
+	}
+
+	res := &DryRunResult{
+		URL:          req.URL,
+		Scraper:      kind,
+		SourceType:   sourceType,
+		Since:        cycle.since,
+		RulesVersion: cycle.rules.Version(),
+	}
+	rawJobs, relaxed, err := fetchDryRun(scr, sourceType, cycle.since, req.Relaxed)
+	res.Relaxed = relaxed
+	if err != nil {
+		return res, err
+	}
+
+	src := store.Source{URL: req.URL, Type: sourceType}
+	for _, raw := range rawJobs {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		res.Jobs = append(res.Jobs, s.dryRunJob(ctx, cycle, src, raw, req.SkipAI))
+	}
+	return res, nil
+}
+
+// fetchDryRun fetches like the pipeline: FetchJobs first, then the relaxed
+// extraction when a company page yields nothing. Unlike retrySource it does
+// not analyze the page or mark the source.
+func fetchDryRun(scr scraper.JobScraper, sourceType string, since time.Time, forceRelaxed bool) ([]scraper.RawJob, bool, error) {
+	relaxedScr, canRelax := scr.(scraper.RelaxedScraper)
+	if forceRelaxed {
+		if !canRelax {
+			return nil, false, errors.New("scraper has no relaxed mode")
+		}
+		jobs, err := relaxedScr.FetchJobsRelaxed(since)
+		return jobs, true, err
+	}
+
+	jobs, err := scr.FetchJobs(since)
+	if err != nil || len(jobs) > 0 || !canRelax || sourceType == "job_board" {
+		return jobs, false, err
+	}
+	jobs, err = relaxedScr.FetchJobsRelaxed(since)
+	return jobs, true, err
+}
+
+// dryRunJob runs one posting through the enrich, filter and score steps and
+// explains the outcome.
+func (s *IngestionService) dryRunJob(ctx context.Context, cycle ingestionCycle, src store.Source, raw scraper.RawJob, skipAI bool) DryRunJob {
+	job := &pipelineJob{run: &sourceRun{src: src}, raw: raw, seen: time.Now()}
+	out := DryRunJob{
+		URL:      raw.URL,
+		Title:    raw.Title,
+		Company:  raw.Company,
+		Location: raw.Location,
+		PostedAt: nullableTime(raw.PostedAt),
+	}
+
+	job.desc = raw.Description
+	if normalized, err := s.normalizer.Normalize(raw.Description); err == nil && normalized != "" {
+		job.desc = normalized
+	}
+	job.class = classifyJob(raw.Title, job.desc)
+
+	job.candidates = s.profileCandidates(cycle, store.JobFingerprint{}, job, nil)
+	if skipAI {
+		job.facts = mergeFacts(raw, ai.HeuristicFacts(ai.JobData{Title: raw.Title, Description: job.desc}))
+	} else {
+		s.extractFacts(ctx, []*pipelineJob{job})
+	}
+	out.Facts = job.facts
+	out.Seniority = job.class.Seniority
+	out.RoleFamily = job.class.RoleFamily
+	out.ExperienceYears = job.class.ExperienceYears
+	for i := range job.candidates {
+		c := &job.candidates[i]
+		if !c.needsAI {
+			continue
+		}
+		if skipAI {
+			c.score, c.summary = c.keywords.Score, "Rule-based match only"
+			continue
+		}
+		match, err := s.matcher.Match(ctx, raw.Title, job.desc, candidateProfile(c.profile))
+		applyMatch(c, MatchResult{Match: match, Err: err})
+	}
+	// Nothing is stored for the job yet, so every candidate has a match.
+	matches := s.finalizeMatches(cycle.rules, job)
+
+	var reasons []string
+	for i := range job.candidates {
+		score := explainProfile(&job.candidates[i], matches[i])
+		out.Passed = out.Passed || score.Passed
+		out.Profiles = append(out.Profiles, score)
+		reasons = append(reasons, score.Profile+": "+score.Reason)
+	}
+	out.Reason = strings.Join(reasons, "; ")
+	return out
+}
+
+// explainProfile reports the profile's score for the job and why it would or
+// would not be stored.
+func explainProfile(c *profileCandidate, m store.ProfileMatch) DryRunScore {
+	score := DryRunScore{
+		Profile:   c.profile.Name,
+		Passed:    m.Matched,
+		Rule:      storedDecision(c.final),
+		RuleScore: c.keywords.Score,
+		Summary:   c.summary,
+	}
+	if c.decision.Rejected() {
+		score.Reason = fmt.Sprintf("rejected by rule %q", c.decision.RuleName)
+		return score
+	}
+
+	if c.match != nil {
+		score.AIScore = &c.match.MatchScore
+	}
+	score.Score = m.MatchScore
+	score.Reason = fmt.Sprintf("score %d", score.Score)
+	switch {
+	case !c.needsAI:
+		score.Reason += " (no keyword matches)"
+	case c.aiFailed:
+		score.Reason += " (AI failed, keywords only)"
+	}
+	switch {
+	case c.final.Accepted():
+		score.Reason += fmt.Sprintf(", accepted by rule %q", c.final.RuleName)
+	case c.final.Rejected():
+		score.Reason += fmt.Sprintf(", rejected by rule %q", c.final.RuleName)
+	}
+	return score
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/baxromumarov/job-hunter/internal/ai"
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/scraper"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// extractFacts sets the facts of a batch of jobs. Jobs that are going to be
+// AI-scored are read by the AI client, up to AI_MATCH_BATCH_SIZE per request
+// and matchBatchParallel requests at a time, unless AI_EXTRACT_FACTS is off
+// or the AI budget is nearly spent; the others get the heuristic facts. Jobs
+// whose content did not change keep their stored facts.
+func (s *IngestionService) extractFacts(ctx context.Context, jobs []*pipelineJob) {
+	withAI := s.extractWithAI && !s.matcher.budget.tight(ctx)
+	var pending []*pipelineJob
+	for _, job := range jobs {
+		if job.unchanged {
+			continue
+		}
+		if withAI && slices.ContainsFunc(job.candidates, func(c profileCandidate) bool { return c.needsAI }) {
+			pending = append(pending, job)
+			continue
+		}
+		job.facts = mergeFacts(job.raw, ai.HeuristicFacts(ai.JobData{Title: job.raw.Title, Description: job.desc}))
+	}
+
+	sem := make(chan struct{}, matchBatchParallel)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for chunk := range slices.Chunk(pending, s.matcher.batchSize) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.readFacts(ctx, chunk)
+		}()
+	}
+}
+
+// readFacts asks the AI client for the facts of a chunk of postings in one
+// request. Postings the answer left out, and all of them when the request
+// fails or the AI budget is spent, get the heuristic facts instead.
+func (s *IngestionService) readFacts(ctx context.Context, chunk []*pipelineJob) {
+	data := make([]ai.JobData, len(chunk))
+	for i, job := range chunk {
+		data[i] = ai.JobData{Title: job.raw.Title, Description: job.desc}
+	}
+	answer, err := s.matcher.ExtractFacts(ctx, data)
+	if err != nil && ctx.Err() == nil && !errors.Is(err, ErrAIBudgetExhausted) {
+		observability.IncError(observability.ErrorAI, "extraction")
+		slog.Warn("ai fact extraction failed, using heuristics", "jobs", len(chunk), "error", err)
+	}
+	for i, job := range chunk {
+		if err == nil && answer.Errs[i] == nil {
+			job.facts = mergeFacts(job.raw, answer.Facts[i])
+			if fact := answer.Facts[i].ExperienceYears; fact.Known() && fact.Confidence >= experienceConfidence {
+				job.class = job.class.withExperience(job.raw.Title, fact.Value)
+				job.facts.ExperienceYears = extractedFact(fact, answer.Facts[i].Model)
+			}
+			continue
+		}
+		if err == nil {
+			slog.Warn("ai fact extraction incomplete, using heuristics", "title", job.raw.Title, "error", answer.Errs[i])
+		}
+		job.facts = mergeFacts(job.raw, ai.HeuristicFacts(data[i]))
+	}
+}
+
+// experienceConfidence is how sure an AI reading of the required years must be
+// to replace the classifier's.
+const experienceConfidence = 0.7
+
+// withExperience replaces the classifier's experience requirement with one
+// the AI read confidently, together with the seniority it implies when the
+// title names none.
+func (c JobClass) withExperience(title string, years int) JobClass {
+	c.ExperienceYears = years
+	if firstGroup(seniorityTerms, title) == "" {
+		c.Seniority = seniorityFromYears(c.ExperienceYears)
+	}
+	return c
+}
+
+// mergeFacts combines what the board published with the extracted facts.
+// Scraper data wins wherever the board provides it.
+func mergeFacts(raw scraper.RawJob, extracted ai.JobFacts) *store.JobFacts {
+	facts := &store.JobFacts{
+		VisaSponsorship: extractedFact(extracted.VisaSponsorship, extracted.Model),
+		RemoteRegions:   extractedFact(extracted.RemoteRegions, extracted.Model),
+		TechStack:       extractedFact(extracted.TechStack, extracted.Model),
+		EmploymentType:  extractedFact(extracted.EmploymentType, extracted.Model),
+	}
+	switch {
+	case raw.SalaryMax > 0:
+		facts.Salary = &store.JobFact[store.Salary]{
+			Value: store.Salary{
+				Min:      min(raw.SalaryMin, raw.SalaryMax),
+				Max:      raw.SalaryMax,
+				Currency: strings.ToUpper(raw.SalaryCurrency),
+				Period:   ai.NormalizeSalaryPeriod(raw.SalaryPeriod),
+			},
+			Confidence: 1,
+			Source:     store.FactSourceScraper,
+		}
+	case extracted.Salary.Known():
+		facts.Salary = &store.JobFact[store.Salary]{
+			Value:      store.Salary(extracted.Salary.Value),
+			Confidence: extracted.Salary.Confidence,
+			Source:     extracted.Model,
+		}
+	}
+	if kind := ai.NormalizeEmploymentType(raw.EmploymentType); kind != "" {
+		facts.EmploymentType = &store.JobFact[string]{Value: kind, Confidence: 1, Source: store.FactSourceScraper}
+	}
+	return facts
+}
+
+func extractedFact[T any](fact ai.Fact[T], source string) *store.JobFact[T] {
+	if !fact.Known() {
+		return nil
+	}
+	return &store.JobFact[T]{Value: fact.Value, Confidence: fact.Confidence, Source: source}
+}
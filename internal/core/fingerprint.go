@@ -0,0 +1,17 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// jobContentHash fingerprints the parts of a posting that influence its score.
+func jobContentHash(title, description, location string) string {
+	h := sha256.New()
+	for _, part := range []string{title, description, location} {
+		h.Write([]byte(strings.Join(strings.Fields(part), " ")))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
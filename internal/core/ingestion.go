@@ -2,6 +2,9 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/url"
 	"os"
@@ -10,14 +13,13 @@ import (
 	"sync"
 	"time"
 
-	"github.com/baxromumarov/job-hunter/internal/ai"
 	"github.com/baxromumarov/job-hunter/internal/content"
 	"github.com/baxromumarov/job-hunter/internal/httpx"
 	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/rules"
 	"github.com/baxromumarov/job-hunter/internal/scraper"
 	"github.com/baxromumarov/job-hunter/internal/store"
 	"github.com/baxromumarov/job-hunter/internal/urlutil"
-	"golang.org/x/sync/errgroup"
 	"golang.org/x/time/rate"
 )
 
@@ -25,341 +27,496 @@ type IngestionService struct {
 	store      *store.Store
 	matcher    *MatcherService
 	normalizer scraper.Normalizer
-	keywords   []string
-	blockedLoc []string
-	blockedJob []string
-	profile    ai.CandidateProfile
 	fetcher    *httpx.CollyFetcher
-	minMatch   int
+	pipeline   pipelineConfig
+	policy     scrapePolicy
+	breaker    circuitBreaker
+	retention  store.RetentionPolicy
 	hostLimits map[string]*rate.Limiter
 	hostMu     sync.Mutex
+	// rescoreAIPerMinute caps the AI calls of a rescore that sets no rate.
+	rescoreAIPerMinute int
+	// extractWithAI sends postings to the AI client for fact extraction;
+	// without it only the heuristic extractor runs.
+	extractWithAI bool
+
+	// runCtx is the context passed to Start; manually triggered runs use it
+	// so they outlive the HTTP request that started them.
+	runCtx context.Context
+	// wg tracks the loops and manual runs so shutdown can wait for them.
+	wg sync.WaitGroup
+	// tasks processes queued score and verify work on every replica.
+	tasks *TaskWorker
 }
 
-func NewIngestionService(store *store.Store, matcher *MatcherService) *IngestionService {
-	minMatch := clampMatchScore(intFromEnv("JOB_MIN_MATCH_SCORE", 60))
-	return &IngestionService{
-		store:      store,
+func NewIngestionService(st *store.Store, matcher *MatcherService) *IngestionService {
+	s := &IngestionService{
+		store:      st,
 		matcher:    matcher,
 		normalizer: scraper.NewSimpleNormalizer(),
-		keywords: []string{
-			"golang",
-			"go developer",
-			"go engineer",
-			"backend",
-			"backend engineer",
-			"backend developer",
-			"platform engineer",
-			"infrastructure engineer",
-			"distributed systems",
-			"microservices",
-			"grpc",
-			"api",
-			"software engineer",
-			"site reliability",
-			"sre",
-		},
-		blockedLoc: []string{"india", "delhi", "mumbai", "bangalore", "bengaluru", "korea", "south korea", "seoul", "japan", "tokyo", "china", "beijing", "shanghai"},
-		blockedJob: []string{
-			"sales",
-			"account executive",
-			"account manager",
-			"account director",
-			"business development",
-			"bdm",
-			"bdr",
-			"sdr",
-			"customer success",
-			"marketing",
-			"recruiter",
-			"talent acquisition",
-			"human resources",
-			"hr ",
-			"finance",
-			"accounting",
-			"legal",
-			"partnership",
-			"partnerships",
-			"salesforce",
-		},
-		profile: ai.CandidateProfile{
-			TechStack: []string{"golang", "backend", "grpc", "rest", "postgresql", "redis", "docker", "linux"},
-		},
 		fetcher:    httpx.NewCollyFetcher("job-hunter-bot/1.0"),
-		minMatch:   minMatch,
+		pipeline:   pipelineConfigFromEnv(max(intFromEnv("INGESTION_WORKERS", 6), 1)),
+		policy:     scrapePolicyFromEnv(),
+		breaker:    circuitBreakerFromEnv(),
+		retention:  retentionPolicyFromEnv(),
 		hostLimits: make(map[string]*rate.Limiter),
+		runCtx:     context.Background(),
+		tasks:      NewTaskWorker(st, intFromEnv("TASK_WORKERS", 4)),
+
+		rescoreAIPerMinute: max(intFromEnv("RESCORE_AI_PER_MINUTE", 30), 1),
+		extractWithAI:      !strings.EqualFold(os.Getenv("AI_EXTRACT_FACTS"), "false"),
 	}
+	s.tasks.Handle(store.TaskScoreJob, s.scoreJobTask)
+	s.tasks.Handle(store.TaskVerifyJob, s.verifyJobTask)
+	return s
 }
 
+// Start launches the task worker and remembers ctx for manual runs. The
+// scheduled ingestion and retention passes are run by the Scheduler through
+// RunScheduledIngestion and RunRetention. Wait blocks until the worker and
+// any manual runs have drained.
 func (s *IngestionService) Start(ctx context.Context) {
-	go s.scrapeLoop(ctx, 30*time.Minute)
-	go s.cleanupLoop(ctx, 24*time.Hour, 30*24*time.Hour)
+	s.runCtx = ctx
+	s.goTracked(func() { s.tasks.Run(ctx) })
 }
 
-func (s *IngestionService) scrapeLoop(ctx context.Context, interval time.Duration) {
-	s.scrapeOnce(ctx)
+// Wait blocks until the task worker and every manual run have returned, or until ctx
+// is done, in which case it returns ctx.Err().
+func (s *IngestionService) Wait(ctx context.Context) error {
+	return WaitGroup(ctx, &s.wg)
+}
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+func (s *IngestionService) goTracked(fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn()
+	}()
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			s.scrapeOnce(ctx)
-		}
-	}
+// ingestionCycle holds the configuration snapshot shared by every source in one scrape cycle.
+type ingestionCycle struct {
+	profiles []store.Profile
+	rules    *rules.Set
+	since    time.Time
+	// ignoreCircuits lets a manual scrape of one source through open circuits.
+	ignoreCircuits bool
 }
 
-func (s *IngestionService) scrapeOnce(ctx context.Context) {
-	sources, _, err := s.store.ListSources(ctx, 200, 0)
-	if err != nil {
-		observability.IncError(observability.ErrorStore, "ingestion")
-		slog.Error("ingestion list sources failed", "error", err)
-		return
-	}
+// sourcePageSize is how many sources an ingestion run loads per keyset page.
+const sourcePageSize = 200
 
-	since := time.Now().Add(-10 * 24 * time.Hour)
+// scrapeVisibility is how long a claimed scrape task stays hidden from other
+// workers; a source that takes longer is handed out again.
+const scrapeVisibility = 30 * time.Minute
 
-	workerCount := 6
-	srcCh := make(chan store.Source)
+var errNoActiveProfiles = errors.New("no active profiles")
 
-	g, gctx := errgroup.WithContext(ctx)
-	for i := 0; i < workerCount; i++ {
-		g.Go(func() error {
-			for src := range srcCh {
-				select {
-				case <-gctx.Done():
-					return gctx.Err()
-				default:
-				}
-				s.processSource(gctx, src, since)
-			}
+// RunScheduledIngestion queues scrape tasks for the due sources and runs an
+// ingestion over them. Sources carry their own next_scrape_at, so the
+// schedule only sets how often they are polled. A run is only opened when
+// there is work: a task was queued, or a ready task was left behind (e.g. by
+// a replica that died). Due sources whose task another run holds do not
+// count. It does nothing while another ingestion run, such as a manual one,
+// is still active; that run picks up the queued tasks.
+func (s *IngestionService) RunScheduledIngestion(ctx context.Context) error {
+	enqueued, err := s.enqueueSources(ctx, true)
+	if err != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		return fmt.Errorf("enqueue due sources: %w", err)
+	}
+	if enqueued == 0 {
+		ready, err := s.store.CountReadyTasks(ctx, store.TaskScrapeSource)
+		if err != nil {
+			observability.IncError(observability.ErrorStore, "ingestion")
+			return fmt.Errorf("count ready scrape tasks: %w", err)
+		}
+		if ready == 0 {
 			return nil
-		})
+		}
 	}
 
-	for _, src := range sources {
-		if gctx.Err() != nil {
-			break
-		}
-		srcCh <- src
+	run, created, err := s.store.CreateRun(ctx, store.RunKindIngestion, 0, store.RunTriggerSchedule)
+	if err != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		return fmt.Errorf("create run: %w", err)
 	}
-	close(srcCh)
-	_ = g.Wait()
+	if !created {
+		slog.Info("ingestion skip cycle", "reason", "run_active", "run_id", run.ID)
+		return nil
+	}
+	return s.executeIngestion(ctx, run, nil)
 }
 
-func (s *IngestionService) cleanupLoop(ctx context.Context, interval, retention time.Duration) {
-	s.cleanup(ctx, retention)
+// TriggerIngestion starts a manual ingestion run over every eligible source,
+// due or not. If an ingestion run is already active it is returned instead
+// and created is false.
+func (s *IngestionService) TriggerIngestion(ctx context.Context) (*store.Run, bool, error) {
+	run, created, err := s.store.CreateRun(ctx, store.RunKindIngestion, 0, store.RunTriggerManual)
+	if err != nil || !created {
+		return run, false, err
+	}
+	s.goTracked(func() {
+		s.executeIngestion(s.runCtx, run, func(ctx context.Context) error {
+			_, err := s.enqueueSources(ctx, false)
+			return err
+		})
+	})
+	return run, true, nil
+}
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// TriggerSourceScrape starts a manual scrape of one source. Concurrent
+// triggers for the same source coalesce onto the active run, and so does a
+// trigger while an ingestion run is active: the source is queued for that
+// run instead. It returns store.ErrNotFound for unknown sources.
+func (s *IngestionService) TriggerSourceScrape(ctx context.Context, sourceID int) (*store.Run, bool, error) {
+	src, err := s.store.GetSource(ctx, sourceID)
+	if err != nil {
+		return nil, false, err
+	}
+	if src == nil {
+		return nil, false, store.ErrNotFound
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			s.cleanup(ctx, retention)
-		}
+	run, created, err := s.store.CreateRun(ctx, store.RunKindSourceScrape, sourceID, store.RunTriggerManual)
+	if err != nil {
+		return nil, false, err
 	}
+	if !created {
+		if run.Kind == store.RunKindIngestion {
+			_, _, err = s.enqueueSource(ctx, sourceID)
+		}
+		return run, false, err
+	}
+	s.goTracked(func() {
+		ctx := s.runCtx
+		tracker := NewRunTracker(s.store, run)
+		tracker.Start(ctx)
+		_, _, err := s.enqueueSource(ctx, sourceID)
+		if err == nil {
+			err = s.ingest(ctx, tracker, scrapeTaskKey(sourceID))
+		}
+		tracker.Finish(ctx, err)
+	})
+	return run, true, nil
 }
 
-func (s *IngestionService) cleanup(ctx context.Context, retention time.Duration) {
-	deleted, err := s.store.DeleteOldJobs(ctx, retention)
-	if err != nil {
-		observability.IncError(observability.ErrorStore, "ingestion")
-		slog.Error("ingestion cleanup failed", "error", err)
-		return
+// executeIngestion runs enqueue, when set, and then ingests every ready
+// scrape task under run.
+func (s *IngestionService) executeIngestion(ctx context.Context, run *store.Run, enqueue func(context.Context) error) error {
+	tracker := NewRunTracker(s.store, run)
+	tracker.Start(ctx)
+	var err error
+	if enqueue != nil {
+		err = enqueue(ctx)
 	}
-	if deleted > 0 {
-		slog.Info("ingestion cleanup removed expired jobs", "count", deleted)
+	if err == nil {
+		err = s.ingest(ctx, tracker, "")
 	}
+	if err != nil && ctx.Err() == nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		slog.Error("ingestion run failed", "run_id", run.ID, "error", err)
+	}
+	tracker.Finish(ctx, err)
+	return err
 }
 
-func (s *IngestionService) scoreJob(ctx context.Context, title, description string) (int, string) {
-	if s.isBlockedJob(title, description) {
-		return 0, ""
+// loadCycle snapshots the profiles and filter rules an ingestion run scores against.
+func (s *IngestionService) loadCycle(ctx context.Context) (ingestionCycle, error) {
+	profiles, err := s.store.ListProfiles(ctx, true)
+	if err != nil {
+		return ingestionCycle{}, err
 	}
-	ruleScore := s.ruleScore(title + " " + description)
-	if ruleScore == 0 {
-		return 0, ""
+	if len(profiles) == 0 {
+		return ingestionCycle{}, errNoActiveProfiles
 	}
 
-	match, err := s.matcher.Match(ctx, title, description, s.profile)
+	ruleSet, err := loadRuleSet(ctx, s.store)
 	if err != nil {
-		observability.IncError(observability.ErrorAI, "ingestion")
-		slog.Warn("ingestion ai match failed", "error", err)
-		return ruleScore, "Rule-based match only"
+		return ingestionCycle{}, err
 	}
 
-	final := int(float64(ruleScore)*0.4 + float64(match.MatchScore)*0.6)
-	return final, match.ShortSummary
+	return ingestionCycle{
+		profiles: profiles,
+		rules:    ruleSet,
+		since:    time.Now().AddDate(0, 0, -maxAgeDays),
+	}, nil
 }
 
-func (s *IngestionService) ruleScore(text string) int {
-	lower := strings.ToLower(text)
-	hits := 0
-	for _, kw := range s.keywords {
-		if strings.Contains(lower, kw) {
-			hits++
+func scrapeTaskKey(sourceID int) string {
+	return strconv.Itoa(sourceID)
+}
+
+func (s *IngestionService) enqueueSource(ctx context.Context, sourceID int) (*store.Task, bool, error) {
+	return s.store.EnqueueTask(ctx, store.NewTask{
+		Type:    store.TaskScrapeSource,
+		Key:     scrapeTaskKey(sourceID),
+		Payload: ScrapeSourcePayload{SourceID: sourceID},
+	})
+}
+
+// enqueueSources queues a scrape task for every eligible source (or every due
+// one), one keyset page at a time, and returns how many tasks it created.
+// Sources that already have a pending task keep it.
+func (s *IngestionService) enqueueSources(ctx context.Context, dueOnly bool) (int, error) {
+	afterID, enqueued := 0, 0
+	for {
+		page, err := s.store.ListScrapeSources(ctx, afterID, sourcePageSize, dueOnly)
+		if err != nil {
+			return enqueued, err
+		}
+		for _, src := range page {
+			_, created, err := s.enqueueSource(ctx, src.ID)
+			if err != nil {
+				return enqueued, err
+			}
+			if created {
+				enqueued++
+			}
 		}
+		if len(page) < sourcePageSize {
+			return enqueued, nil
+		}
+		afterID = page[len(page)-1].ID
 	}
+}
 
-	switch {
-	case hits >= 4:
-		return 95
-	case hits == 3:
-		return 85
-	case hits == 2:
-		return 75
-	case hits == 1:
-		return 60
-	default:
-		return 0
+// ingest claims ready scrape tasks (only the one for key, if set) and streams
+// their sources through the pipeline. Tasks are claimed a few at a time so
+// none sits claimed in memory long enough for its visibility to lapse.
+func (s *IngestionService) ingest(ctx context.Context, tracker *RunTracker, key string) error {
+	cycle, err := s.loadCycle(ctx)
+	if err != nil {
+		return err
 	}
+	cycle.ignoreCircuits = key != ""
+
+	intake, cancel := IntakeContext(ctx)
+	defer cancel()
+
+	start := time.Now()
+	srcCh := make(chan scrapeTask)
+	var processed, skipped int
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		processed, skipped = s.runPipeline(ctx, cycle, srcCh, tracker)
+	}()
+
+produce:
+	for {
+		var tasks []store.Task
+		tasks, err = ClaimTasks(ctx, s.store, store.TaskScrapeSource, key, s.pipeline.fetchWorkers, scrapeVisibility)
+		if err != nil || len(tasks) == 0 {
+			break
+		}
+		for i, task := range tasks {
+			src, err := s.taskSource(ctx, task)
+			if err != nil || src == nil {
+				// Unknown or ineligible sources simply complete their task.
+				FinishTask(ctx, s.store, task, err)
+				continue
+			}
+			select {
+			case srcCh <- scrapeTask{src: *src, task: task}:
+			case <-intake.Done():
+				for _, rest := range tasks[i:] {
+					ReleaseTask(ctx, s.store, rest)
+				}
+				break produce
+			}
+		}
+	}
+	close(srcCh)
+	<-finished
+
+	observability.AddSourcesProcessed(processed)
+	observability.AddSourcesSkipped(skipped)
+	slog.Info("ingestion run finished",
+		"processed", processed,
+		"skipped", skipped,
+		"workers", s.pipeline.fetchWorkers,
+		"duration", time.Since(start).String(),
+	)
+	return err
+}
+
+// taskSource loads the source a scrape task points at, or nil if it was deleted.
+func (s *IngestionService) taskSource(ctx context.Context, task store.Task) (*store.Source, error) {
+	var payload ScrapeSourcePayload
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("decode scrape task: %w", err)
+	}
+	return s.store.GetSource(ctx, payload.SourceID)
 }
 
-func (s *IngestionService) isBlockedLocation(loc string) bool {
+// runRetention is how long finished runs are kept.
+const runRetention = 30 * 24 * time.Hour
+
+// RunRetention deletes expired jobs, finished tasks and runs and expired AI
+// answers and queues liveness checks for postings that were not verified
+// recently.
+func (s *IngestionService) RunRetention(ctx context.Context) error {
+	err := s.deleteExpiredJobs(ctx)
+
+	if n, terr := s.store.DeleteFinishedTasks(ctx, 7*24*time.Hour); terr != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		slog.Error("ingestion task cleanup failed", "error", terr)
+		err = errors.Join(err, terr)
+	} else if n > 0 {
+		slog.Info("ingestion cleanup removed finished tasks", "count", n)
+	}
+	if n, rerr := s.store.DeleteFinishedRuns(ctx, runRetention); rerr != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		slog.Error("ingestion run cleanup failed", "error", rerr)
+		err = errors.Join(err, rerr)
+	} else if n > 0 {
+		slog.Info("ingestion cleanup removed finished runs", "count", n)
+	}
+	if n, cerr := s.store.DeleteExpiredAIResponses(ctx); cerr != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		slog.Error("ingestion ai cache cleanup failed", "error", cerr)
+		err = errors.Join(err, cerr)
+	} else if n > 0 {
+		slog.Info("ingestion cleanup removed expired ai answers", "count", n)
+	}
+	s.enqueueVerifications(ctx)
+	return err
+}
+
+// isBlockedLocation applies the profile's location policy. Jobs without a
+// location are kept since most boards leave it empty for remote roles.
+func isBlockedLocation(profile store.Profile, loc string) bool {
 	if loc == "" {
 		return false
 	}
 	l := strings.ToLower(loc)
-	for _, b := range s.blockedLoc {
+	for _, b := range profile.Location.Blocked {
 		if strings.Contains(l, b) {
 			return true
 		}
 	}
+	remote := strings.Contains(l, "remote") || strings.Contains(l, "anywhere")
+	if profile.Location.RemoteOnly && !remote {
+		return true
+	}
+	if len(profile.Location.Allowed) > 0 && !remote {
+		for _, a := range profile.Location.Allowed {
+			if strings.Contains(l, a) {
+				return false
+			}
+		}
+		return true
+	}
 	return false
 }
 
-func (s *IngestionService) isBlockedJob(title, description string) bool {
+// excludedKeyword returns the first exclude keyword of the profile found in
+// the posting, or "".
+func excludedKeyword(profile store.Profile, title, description string) string {
 	text := strings.ToLower(strings.TrimSpace(title + " " + description))
 	if text == "" {
-		return false
+		return ""
 	}
-	for _, kw := range s.blockedJob {
+	for _, kw := range profile.ExcludeKeywords {
 		if kw == "" {
 			continue
 		}
 		if strings.Contains(text, kw) {
-			return true
+			return kw
 		}
 	}
-	return false
+	return ""
+}
+
+// Scraper kinds. pickScraper chooses one from the source's host; a dry run
+// can force one.
+const (
+	ScraperRemoteOK   = "remoteok"
+	ScraperWWR        = "wwr"
+	ScraperAshby      = "ashby"
+	ScraperLever      = "lever"
+	ScraperGreenhouse = "greenhouse"
+	ScraperGeneric    = "generic"
+)
+
+// ScraperKinds lists the scraper kinds newScraper accepts.
+var ScraperKinds = []string{ScraperRemoteOK, ScraperWWR, ScraperAshby, ScraperLever, ScraperGreenhouse, ScraperGeneric}
+
+func (s *IngestionService) pickScraper(rawURL string) scraper.JobScraper {
+	scr, _ := newScraper(scraperKind(rawURL), rawURL)
+	return scr
 }
 
-func (s *IngestionService) pickScraper(rawURL, sourceType string) scraper.JobScraper {
+// scraperKind picks the scraper for a URL by host, falling back to generic.
+func scraperKind(rawURL string) string {
 	u, err := url.Parse(rawURL)
 	if err != nil {
-		return scraper.NewGenericScraper(rawURL)
+		return ScraperGeneric
 	}
 	host := u.Host
 
 	switch {
 	case strings.Contains(host, "remoteok.com"):
-		return scraper.NewRemoteOKScraper("golang")
+		return ScraperRemoteOK
 	case strings.Contains(host, "weworkremotely.com"):
-		return scraper.NewWWRScraper()
+		return ScraperWWR
 	case strings.Contains(host, "ashbyhq.com"):
-		return scraper.NewAshbyScraper(rawURL)
+		return ScraperAshby
 	case strings.Contains(host, "lever.co"):
-		return scraper.NewLeverScraper(rawURL)
-	case strings.Contains(host, "greenhouse.io") || strings.Contains(host, "boards.greenhouse.io"):
-		return scraper.NewGreenhouseScraper(rawURL)
+		return ScraperLever
+	case strings.Contains(host, "greenhouse.io"):
+		return ScraperGreenhouse
 	default:
-		// Fall back to generic
-		_ = sourceType
-		return scraper.NewGenericScraper(rawURL)
+		return ScraperGeneric
 	}
 }
 
-func (s *IngestionService) processSource(ctx context.Context, src store.Source, since time.Time) {
-	start := time.Now()
-	defer observability.ObserveCrawlDuration(src.Type, time.Since(start).Seconds())
-
-	limiter := s.hostLimiter(src.URL)
-	if limiter != nil {
-		if err := limiter.Wait(ctx); err != nil {
-			observability.IncError(observability.ErrorRateLimit, "ingestion")
-			return
-		}
+func newScraper(kind, rawURL string) (scraper.JobScraper, error) {
+	switch kind {
+	case ScraperRemoteOK:
+		return scraper.NewRemoteOKScraper("golang"), nil
+	case ScraperWWR:
+		return scraper.NewWWRScraper(), nil
+	case ScraperAshby:
+		return scraper.NewAshbyScraper(rawURL), nil
+	case ScraperLever:
+		return scraper.NewLeverScraper(rawURL), nil
+	case ScraperGreenhouse:
+		return scraper.NewGreenhouseScraper(rawURL), nil
+	case ScraperGeneric:
+		return scraper.NewGenericScraper(rawURL), nil
+	default:
+		return nil, fmt.Errorf("unknown scraper %q (want one of %s)", kind, strings.Join(ScraperKinds, ", "))
 	}
+}
 
-	scr := s.pickScraper(src.URL, src.Type)
-	rawJobs, err := scr.FetchJobs(since)
-	if err != nil {
-		errType := observability.ClassifyScrapeError(err)
-		observability.IncError(errType, "ingestion")
-		_ = s.store.MarkSourceError(ctx, src.ID, errType, err.Error())
-		slog.Error("ingestion scrape failed", "url", src.URL, "error", err)
-		return
-	}
-	if len(rawJobs) == 0 {
-		if retried, handled := s.retrySource(ctx, src, scr, since); handled {
-			rawJobs = retried
-		}
+// reschedule stores the source's next scrape time derived from this attempt.
+func (s *IngestionService) reschedule(ctx context.Context, src store.Source, newJobs int, failed bool) {
+	schedule := s.policy.next(src, newJobs, failed, time.Now())
+	if err := s.store.UpdateSourceSchedule(ctx, src.ID, schedule); err != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		slog.Error("ingestion reschedule source failed", "source_id", src.ID, "error", err)
 	}
+}
+
+// knownFingerprints loads the stored fingerprints for a batch of scraped jobs.
+// A lookup failure only disables the unchanged-job shortcut for this batch.
+func (s *IngestionService) knownFingerprints(ctx context.Context, rawJobs []scraper.RawJob) map[string]store.JobFingerprint {
 	if len(rawJobs) == 0 {
-		observability.IncSourcesZeroJobs(src.Type)
+		return nil
 	}
-
+	urls := make([]string, 0, len(rawJobs))
 	for _, raw := range rawJobs {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
-
-		postDate := raw.PostedAt
-		if !postDate.IsZero() && postDate.Before(since) {
-			continue
-		}
-
-		desc := raw.Description
-		if normalized, err := s.normalizer.Normalize(raw.Description); err == nil && normalized != "" {
-			desc = normalized
-		}
-
-		if s.isBlockedLocation(raw.Location) {
-			continue
-		}
-
-		finalScore, summary := s.scoreJob(ctx, raw.Title, desc)
-		if finalScore < s.minMatch {
-			continue
-		}
-
-		job := store.Job{
-			SourceID:     src.ID,
-			SourceURL:    src.URL,
-			SourceType:   src.Type,
-			URL:          raw.URL,
-			Title:        raw.Title,
-			Description:  desc,
-			Company:      raw.Company,
-			Location:     raw.Location,
-			MatchScore:   finalScore,
-			MatchSummary: summary,
-			PostedAt:     nullableTime(postDate),
-		}
-
-		if err := s.store.SaveJob(ctx, job); err != nil {
-			observability.IncError(observability.ErrorStore, "ingestion")
-			_ = s.store.MarkSourceError(ctx, src.ID, observability.ErrorStore, err.Error())
-			slog.Error("ingestion save job failed", "url", raw.URL, "error", err)
-			continue
-		}
-		observability.IncJobsDiscovered(src.Type)
-		observability.IncJobsExtracted(src.Type)
+		urls = append(urls, raw.URL)
 	}
-
-	if err := s.store.MarkSourceScraped(ctx, src.ID); err != nil {
+	known, err := s.store.GetJobFingerprints(ctx, urls)
+	if err != nil {
 		observability.IncError(observability.ErrorStore, "ingestion")
-		slog.Error("ingestion mark source scraped failed", "source_id", src.ID, "error", err)
-		return
+		slog.Error("ingestion fingerprint lookup failed", "error", err)
+		return nil
 	}
-	_ = s.store.ClearSourceError(ctx, src.ID)
+	return known
 }
 
 func (s *IngestionService) retrySource(ctx context.Context, src store.Source, scr scraper.JobScraper, since time.Time) ([]scraper.RawJob, bool) {
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// Open postings are re-checked once they have gone verifyAfter without a
+// check; each retention pass queues at most verifyBatch of them.
+const (
+	verifyAfter = 7 * 24 * time.Hour
+	verifyBatch = 500
+)
+
+// enqueueScoreRetries queues an AI rescore for every profile whose AI call
+// failed while the job was scored.
+func (s *IngestionService) enqueueScoreRetries(ctx context.Context, job *pipelineJob) {
+	for _, profileID := range job.retryAI {
+		_, _, err := s.store.EnqueueTask(context.WithoutCancel(ctx), store.NewTask{
+			Type:    store.TaskScoreJob,
+			Key:     strconv.Itoa(profileID) + ":" + job.raw.URL,
+			Payload: ScoreJobPayload{JobURL: job.raw.URL, ProfileID: profileID},
+			RunAt:   time.Now().Add(taskBackoffMin),
+		})
+		if err != nil {
+			observability.IncError(observability.ErrorStore, "ingestion")
+			slog.Error("ingestion enqueue score retry failed", "url", job.raw.URL, "error", err)
+		}
+	}
+}
+
+// scoreJobTask retries the AI scoring of one job for one profile and replaces
+// the keyword-only score stored after the original call failed.
+func (s *IngestionService) scoreJobTask(ctx context.Context, task store.Task) error {
+	var payload ScoreJobPayload
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("decode score task: %w", err)
+	}
+
+	stored, err := s.store.GetJobByURL(ctx, payload.JobURL)
+	if err != nil || stored == nil {
+		return err
+	}
+	cycle, err := s.loadCycle(ctx)
+	if err != nil {
+		return err
+	}
+	cycle.profiles = slices.DeleteFunc(cycle.profiles, func(p store.Profile) bool { return p.ID != payload.ProfileID })
+	if len(cycle.profiles) == 0 {
+		return nil
+	}
+
+	// The rules run again so the stored decision matches the new score.
+	job := storedPipelineJob(*stored)
+	job.candidates = s.profileCandidates(cycle, store.JobFingerprint{}, job, nil)
+	c := &job.candidates[0]
+	if !c.needsAI {
+		return nil
+	}
+	match, err := s.matcher.Match(ctx, job.raw.Title, job.desc, candidateProfile(c.profile))
+	if errors.Is(err, ErrAIBudgetExhausted) {
+		// The keyword score stays until a stale-only rescore.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	applyMatch(c, MatchResult{Match: match})
+
+	matches := s.finalizeMatches(cycle.rules, job)
+	err = s.store.UpdateProfileJobScore(ctx, stored.ID, matches[0])
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// enqueueVerifications queues liveness checks for open postings that were
+// not verified recently.
+func (s *IngestionService) enqueueVerifications(ctx context.Context) {
+	jobs, err := s.store.ListJobsToVerify(ctx, time.Now().Add(-verifyAfter), verifyBatch)
+	if err != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		slog.Error("ingestion list jobs to verify failed", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		if _, _, err := s.store.EnqueueTask(ctx, store.NewTask{
+			Type:        store.TaskVerifyJob,
+			Key:         strconv.Itoa(job.ID),
+			Payload:     VerifyJobPayload{JobID: job.ID, URL: job.URL},
+			MaxAttempts: 3,
+		}); err != nil {
+			observability.IncError(observability.ErrorStore, "ingestion")
+			slog.Error("ingestion enqueue verify failed", "job_id", job.ID, "error", err)
+			return
+		}
+	}
+}
+
+// verifyJobTask fetches a posting and closes it when the page is gone.
+func (s *IngestionService) verifyJobTask(ctx context.Context, task store.Task) error {
+	var payload VerifyJobPayload
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("decode verify task: %w", err)
+	}
+
+	_, status, err := s.fetcher.FetchBytes(ctx, payload.URL)
+	if status == http.StatusNotFound || status == http.StatusGone {
+		slog.Info("ingestion posting closed", "job_id", payload.JobID, "url", payload.URL, "status", status)
+		return s.store.MarkJobVerified(ctx, payload.JobID, true)
+	}
+	if err != nil {
+		return err
+	}
+	return s.store.MarkJobVerified(ctx, payload.JobID, false)
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// leaseCheckInterval is how often a standby replica retries a lease and a
+// leader confirms it still holds one.
+const leaseCheckInterval = 15 * time.Second
+
+// Lease names the scheduled jobs that only one replica may run at a time. The
+// scheduler uses a job's name as its lease.
+const (
+	LeaseIngestion     = "ingestion"
+	LeaseRetention     = "retention"
+	LeaseDiscovery     = "discovery"
+	LeaseStatsSnapshot = "stats_snapshot"
+)
+
+// RunWhileLeader runs fn only while this replica holds the named Postgres
+// advisory lock. Every replica calls it; the others wait on standby and take
+// over when the leader's session goes away. fn receives a context that is
+// cancelled when ctx is done or leadership is lost, and RunWhileLeader
+// returns once ctx is done and fn has returned.
+func RunWhileLeader(ctx context.Context, st *store.Store, name string, fn func(ctx context.Context)) {
+	observability.SetLeaseHeld(name, false)
+	for {
+		lock, err := st.TryAdvisoryLock(ctx, name)
+		if err != nil && ctx.Err() == nil {
+			observability.IncError(observability.ErrorStore, "leader")
+			slog.Error("lease acquire failed", "lease", name, "error", err)
+		}
+		if lock != nil {
+			lead(ctx, name, lock, fn)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(leaseCheckInterval):
+		}
+	}
+}
+
+// lead runs fn while lock stays alive, then releases the lock.
+func lead(ctx context.Context, name string, lock *store.AdvisoryLock, fn func(ctx context.Context)) {
+	slog.Info("lease acquired", "lease", name)
+	observability.SetLeaseHeld(name, true)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		fn(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(leaseCheckInterval)
+	defer ticker.Stop()
+watch:
+	for {
+		select {
+		case <-leaderCtx.Done():
+			break watch
+		case <-ticker.C:
+			if err := lock.Alive(leaderCtx); err != nil && leaderCtx.Err() == nil {
+				slog.Error("lease lost", "lease", name, "error", err)
+				break watch
+			}
+		}
+	}
+	cancel()
+	wg.Wait()
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer releaseCancel()
+	if err := lock.Release(releaseCtx); err != nil {
+		slog.Error("lease release failed", "lease", name, "error", err)
+	}
+	observability.SetLeaseHeld(name, false)
+	slog.Info("lease released", "lease", name)
+}
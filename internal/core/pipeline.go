@@ -0,0 +1,738 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/ai"
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/rules"
+	"github.com/baxromumarov/job-hunter/internal/scraper"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// Pipeline stage names, as reported under "pipeline" in /stats.
+const (
+	stageFetch   = "fetch"
+	stageEnrich  = "enrich"
+	stageFilter  = "filter"
+	stageScore   = "score"
+	stagePersist = "persist"
+)
+
+// pipelineConfig sizes the ingestion pipeline. Every stage is connected to the
+// next by a channel holding at most queueSize items, so a slow AI provider
+// backs up into the scrapers instead of growing memory.
+type pipelineConfig struct {
+	fetchWorkers  int
+	enrichWorkers int
+	scoreWorkers  int
+	queueSize     int
+	// aiBatchSize is how many jobs the score stage gathers per step. Their
+	// AI requests are packed into MatchJobs calls by MatchBatch.
+	aiBatchSize   int
+	persistBatch  int
+	flushInterval time.Duration
+}
+
+func pipelineConfigFromEnv(fetchWorkers int) pipelineConfig {
+	return pipelineConfig{
+		fetchWorkers:  fetchWorkers,
+		enrichWorkers: max(intFromEnv("PIPELINE_ENRICH_WORKERS", 2), 1),
+		scoreWorkers:  max(intFromEnv("PIPELINE_SCORE_WORKERS", 2), 1),
+		queueSize:     max(intFromEnv("PIPELINE_QUEUE_SIZE", 64), 1),
+		aiBatchSize:   max(intFromEnv("AI_BATCH_SIZE", 8), 1),
+		persistBatch:  max(intFromEnv("PIPELINE_PERSIST_BATCH", 25), 1),
+		flushInterval: 2 * time.Second,
+	}
+}
+
+// scrapeTask is a claimed scrape task and the source it points at.
+type scrapeTask struct {
+	src  store.Source
+	task store.Task
+}
+
+// sourceRun tracks one source through the pipeline. pending counts the jobs
+// still in flight plus one hold released by the fetch stage; the source is
+// marked scraped and rescheduled when it drops to zero.
+type sourceRun struct {
+	src     store.Source
+	task    store.Task
+	known   map[string]store.JobFingerprint
+	pending atomic.Int64
+	newJobs atomic.Int64
+}
+
+// pipelineJob is one scraped posting moving through the stages.
+type pipelineJob struct {
+	run   *sourceRun
+	raw   scraper.RawJob
+	desc  string
+	class JobClass
+	hash  string
+	// seen is when the posting was first stored, or now for a new one.
+	seen time.Time
+	// unchanged means the stored posting has the same content hash.
+	unchanged  bool
+	candidates []profileCandidate
+	matches    []store.ProfileMatch
+	facts      *store.JobFacts
+	// retryAI lists the profiles whose AI call failed; they get a score_job
+	// task once the job is stored.
+	retryAI []int
+}
+
+// profileCandidate is a profile whose stored outcome for a job may change:
+// its rule decision, what it already holds and the score computed now.
+type profileCandidate struct {
+	profile  store.Profile
+	decision rules.Decision
+	// final is decision after the rules that read score.
+	final   rules.Decision
+	tracked bool
+	stored  store.ProfileFingerprint
+	// reused means the stored breakdown stands in for keyword and AI scoring.
+	reused   bool
+	keywords store.KeywordBreakdown
+	needsAI  bool
+	aiFailed bool
+	match    *ai.JobMatch
+	score    int
+	summary  string
+}
+
+type pipeline struct {
+	svc     *IngestionService
+	cfg     pipelineConfig
+	cycle   ingestionCycle
+	tracker *RunTracker
+
+	received  atomic.Int64
+	processed atomic.Int64
+}
+
+// runPipeline pushes sources through fetch → enrich → filter → score →
+// persist and returns once every stage has drained or ctx is cancelled.
+// Sources whose jobs were not all handled stay due, their tasks are handed
+// back to the queue, and they count as skipped.
+func (s *IngestionService) runPipeline(ctx context.Context, cycle ingestionCycle, sources <-chan scrapeTask, tracker *RunTracker) (processed, skipped int) {
+	p := &pipeline{svc: s, cfg: s.pipeline, cycle: cycle, tracker: tracker}
+
+	fetched := make(chan *pipelineJob, p.cfg.queueSize)
+	enriched := make(chan *pipelineJob, p.cfg.queueSize)
+	filtered := make(chan *pipelineJob, p.cfg.queueSize)
+	scored := make(chan *pipelineJob, p.cfg.queueSize)
+
+	fanOut(p.cfg.fetchWorkers, fetched, func() { p.fetch(ctx, sources, fetched) })
+	fanOut(p.cfg.enrichWorkers, enriched, func() { p.enrich(ctx, fetched, enriched) })
+	fanOut(1, filtered, func() { p.filter(ctx, enriched, filtered) })
+
+	aiBatches := batch(ctx, filtered, p.cfg.aiBatchSize, p.cfg.flushInterval, func(job *pipelineJob) {
+		p.drop(ctx, stageScore, job)
+	})
+	fanOut(p.cfg.scoreWorkers, scored, func() { p.score(ctx, aiBatches, scored) })
+
+	done := make(chan struct{})
+	persistBatches := batch(ctx, scored, p.cfg.persistBatch, p.cfg.flushInterval, func(job *pipelineJob) {
+		p.drop(ctx, stagePersist, job)
+	})
+	fanOut(1, done, func() { p.persist(ctx, persistBatches) })
+	<-done
+
+	processed = int(p.processed.Load())
+	skipped = int(p.received.Load()) - processed
+	tracker.Skipped(skipped)
+	return processed, skipped
+}
+
+func (p *pipeline) fetch(ctx context.Context, sources <-chan scrapeTask, out chan<- *pipelineJob) {
+	s := p.svc
+	for item := range sources {
+		src := item.src
+		p.received.Add(1)
+		if !IntakeOpen(ctx) {
+			ReleaseTask(ctx, s.store, item.task)
+			continue
+		}
+
+		if !p.cycle.ignoreCircuits {
+			if allowed, retryAt := s.circuitAllows(ctx, src); !allowed {
+				observability.IncSourcesCircuitOpen(src.Type)
+				if err := s.store.DeferSourceScrape(ctx, src.ID, retryAt); err != nil {
+					observability.IncError(observability.ErrorStore, "ingestion")
+				}
+				FinishTask(ctx, s.store, item.task, nil)
+				p.processed.Add(1)
+				p.tracker.Processed(1)
+				continue
+			}
+		}
+
+		start := time.Now()
+		limiter := s.hostLimiter(src.URL)
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				observability.IncError(observability.ErrorRateLimit, "ingestion")
+				ReleaseTask(ctx, s.store, item.task)
+				continue
+			}
+		}
+
+		scr := s.pickScraper(src.URL)
+		rawJobs, err := scr.FetchJobs(p.cycle.since)
+		if err != nil {
+			errType := observability.ClassifyScrapeError(err)
+			observability.IncError(errType, "ingestion")
+			_ = s.store.MarkSourceError(ctx, src.ID, errType, err.Error())
+			slog.Error("ingestion scrape failed", "url", src.URL, "error", err)
+			s.recordCircuits(ctx, src, err)
+			// The source's own backoff retries the scrape; failing the task
+			// as well would retry it a second time.
+			s.reschedule(ctx, src, 0, true)
+			FinishTask(ctx, s.store, item.task, nil)
+			p.processed.Add(1)
+			p.tracker.Processed(1)
+			p.tracker.Error(src.URL + ": " + err.Error())
+			observability.ObserveStage(stageFetch, 1, time.Since(start), len(sources))
+			continue
+		}
+		s.recordCircuits(ctx, src, nil)
+		if len(rawJobs) == 0 {
+			if retried, handled := s.retrySource(ctx, src, scr, p.cycle.since); handled {
+				rawJobs = retried
+			}
+		}
+		if len(rawJobs) == 0 {
+			observability.IncSourcesZeroJobs(src.Type)
+		}
+		observability.ObserveCrawlDuration(src.Type, time.Since(start).Seconds())
+		observability.ObserveStage(stageFetch, 1, time.Since(start), len(sources))
+
+		run := &sourceRun{src: src, task: item.task, known: s.knownFingerprints(ctx, rawJobs)}
+		run.pending.Store(1)
+		for _, raw := range rawJobs {
+			run.pending.Add(1)
+			if !send(ctx, out, &pipelineJob{run: run, raw: raw}) {
+				p.release(ctx, run)
+				break
+			}
+			observability.AddStageOut(stageFetch, 1)
+		}
+		p.release(ctx, run)
+	}
+}
+
+func (p *pipeline) enrich(ctx context.Context, in <-chan *pipelineJob, out chan<- *pipelineJob) {
+	for job := range in {
+		start := time.Now()
+		raw := job.raw
+
+		job.desc = raw.Description
+		if normalized, err := p.svc.normalizer.Normalize(raw.Description); err == nil && normalized != "" {
+			job.desc = normalized
+		}
+		job.class = classifyJob(raw.Title, job.desc)
+		job.hash = jobContentHash(raw.Title, job.desc, raw.Location)
+		observability.ObserveStage(stageEnrich, 1, time.Since(start), len(in))
+
+		if !p.forward(ctx, stageEnrich, out, job) {
+			p.discard(ctx, stageEnrich, in)
+			return
+		}
+	}
+}
+
+// filter evaluates the filter rules per profile and computes keyword scores
+// for the profiles whose stored score is stale. A profile that already scored
+// the same content with the same profile version keeps its score; only the
+// rules are applied to it again.
+func (p *pipeline) filter(ctx context.Context, in <-chan *pipelineJob, out chan<- *pipelineJob) {
+	for job := range in {
+		start := time.Now()
+		fp, known := job.run.known[job.raw.URL]
+		job.seen = time.Now()
+		if known && !fp.FirstSeen.IsZero() {
+			job.seen = fp.FirstSeen
+		}
+		job.unchanged = known && fp.ContentHash == job.hash
+		job.candidates = p.svc.profileCandidates(p.cycle, fp, job, func(c *profileCandidate) bool {
+			return job.unchanged && c.stored.Version == c.profile.Version
+		})
+		observability.ObserveStage(stageFilter, 1, time.Since(start), len(in))
+
+		if len(job.candidates) > 0 && !slices.ContainsFunc(job.candidates, func(c profileCandidate) bool { return !c.decision.Rejected() }) {
+			observability.IncJobsRuleRejected(job.run.src.Type)
+		}
+		if len(job.candidates) == 0 {
+			if job.unchanged {
+				observability.IncJobsUnchanged(job.run.src.Type)
+			}
+			p.drop(ctx, stageFilter, job)
+			continue
+		}
+		if !p.forward(ctx, stageFilter, out, job) {
+			p.discard(ctx, stageFilter, in)
+			return
+		}
+	}
+}
+
+// score sends every AI request of a batch of jobs in one MatchBatch call,
+// which packs the jobs of each source and profile into shared requests, and
+// turns the results into per-profile matches. Facts are extracted alongside.
+func (p *pipeline) score(ctx context.Context, in <-chan []*pipelineJob, out chan<- *pipelineJob) {
+	for jobs := range in {
+		start := time.Now()
+		var (
+			reqs    []MatchRequest
+			targets []*profileCandidate
+		)
+		for _, job := range jobs {
+			for i := range job.candidates {
+				c := &job.candidates[i]
+				if !c.needsAI {
+					continue
+				}
+				reqs = append(reqs, MatchRequest{
+					Title:       job.raw.Title,
+					Description: job.desc,
+					Profile:     candidateProfile(c.profile),
+					ProfileID:   c.profile.ID,
+					Group:       job.run.src.ID,
+				})
+				targets = append(targets, c)
+			}
+		}
+
+		var extracted sync.WaitGroup
+		extracted.Add(1)
+		go func() {
+			defer extracted.Done()
+			p.svc.extractFacts(ctx, jobs)
+		}()
+		results := p.svc.matcher.MatchBatch(ctx, reqs)
+		extracted.Wait()
+		if ctx.Err() != nil {
+			p.dropAll(ctx, stageScore, jobs)
+			p.discardBatches(ctx, stageScore, in)
+			return
+		}
+		for i, res := range results {
+			applyMatch(targets[i], res)
+		}
+		observability.ObserveStage(stageScore, len(jobs), time.Since(start), len(in))
+
+		for i, job := range jobs {
+			job.matches = p.svc.finalizeMatches(p.cycle.rules, job)
+			if len(job.matches) == 0 {
+				if job.unchanged {
+					observability.IncJobsUnchanged(job.run.src.Type)
+				}
+				p.drop(ctx, stageScore, job)
+				continue
+			}
+			if !p.forward(ctx, stageScore, out, job) {
+				p.dropAll(ctx, stageScore, jobs[i+1:])
+				p.discardBatches(ctx, stageScore, in)
+				return
+			}
+		}
+	}
+}
+
+// persist writes scored jobs in batches. A batch already taken off the queue
+// is written even during shutdown so scored work is not thrown away.
+func (p *pipeline) persist(ctx context.Context, in <-chan []*pipelineJob) {
+	s := p.svc
+	for jobs := range in {
+		start := time.Now()
+		items := make([]store.JobMatches, len(jobs))
+		for i, job := range jobs {
+			items[i] = store.JobMatches{Job: job.storeJob(), Matches: job.matches}
+		}
+
+		writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		errs := s.store.SaveJobMatchesBatch(writeCtx, items)
+		cancel()
+		observability.ObserveStage(stagePersist, len(jobs), time.Since(start), len(in))
+
+		for i, job := range jobs {
+			if err := errs[i]; err != nil {
+				observability.IncError(observability.ErrorStore, "ingestion")
+				_ = s.store.MarkSourceError(ctx, job.run.src.ID, observability.ErrorStore, err.Error())
+				slog.Error("ingestion save job failed", "url", job.raw.URL, "error", err)
+				observability.AddStageDropped(stagePersist, 1)
+				p.tracker.Error(job.raw.URL + ": " + err.Error())
+			} else {
+				observability.AddStageOut(stagePersist, 1)
+				if job.matched() {
+					observability.IncJobsDiscovered(job.run.src.Type)
+					observability.IncJobsExtracted(job.run.src.Type)
+					p.tracker.JobSaved()
+				}
+				s.enqueueScoreRetries(ctx, job)
+				if _, seen := job.run.known[job.raw.URL]; !seen {
+					job.run.newJobs.Add(1)
+				}
+			}
+			p.release(ctx, job.run)
+		}
+	}
+}
+
+// forward hands job to the next stage. A job that cannot be handed on
+// because ctx is cancelled is dropped.
+func (p *pipeline) forward(ctx context.Context, stage string, out chan<- *pipelineJob, job *pipelineJob) bool {
+	if !send(ctx, out, job) {
+		p.drop(ctx, stage, job)
+		return false
+	}
+	observability.AddStageOut(stage, 1)
+	return true
+}
+
+func (p *pipeline) drop(ctx context.Context, stage string, job *pipelineJob) {
+	observability.AddStageDropped(stage, 1)
+	p.release(ctx, job.run)
+}
+
+func (p *pipeline) dropAll(ctx context.Context, stage string, jobs []*pipelineJob) {
+	for _, job := range jobs {
+		p.drop(ctx, stage, job)
+	}
+}
+
+// discard drops everything still arriving on in after a stage stopped early,
+// until the stages before it close in, so every source's run is released.
+func (p *pipeline) discard(ctx context.Context, stage string, in <-chan *pipelineJob) {
+	for job := range in {
+		p.drop(ctx, stage, job)
+	}
+}
+
+func (p *pipeline) discardBatches(ctx context.Context, stage string, in <-chan []*pipelineJob) {
+	for jobs := range in {
+		p.dropAll(ctx, stage, jobs)
+	}
+}
+
+// release finishes one unit of a source's work. The last one marks the source
+// scraped and reschedules it, unless the run was cancelled part way through.
+func (p *pipeline) release(ctx context.Context, run *sourceRun) {
+	if run.pending.Add(-1) != 0 {
+		return
+	}
+	s := p.svc
+	if ctx.Err() != nil {
+		ReleaseTask(ctx, s.store, run.task)
+		return
+	}
+	err := s.store.MarkSourceScraped(ctx, run.src.ID)
+	if err != nil {
+		observability.IncError(observability.ErrorStore, "ingestion")
+		slog.Error("ingestion mark source scraped failed", "source_id", run.src.ID, "error", err)
+	} else {
+		_ = s.store.ClearSourceError(ctx, run.src.ID)
+		s.reschedule(ctx, run.src, int(run.newJobs.Load()), false)
+	}
+	FinishTask(ctx, s.store, run.task, err)
+	p.processed.Add(1)
+	p.tracker.Processed(1)
+}
+
+func (job *pipelineJob) storeJob() store.Job {
+	src, raw := job.run.src, job.raw
+	return store.Job{
+		SourceID:    src.ID,
+		SourceURL:   src.URL,
+		SourceType:  src.Type,
+		URL:         raw.URL,
+		Title:       raw.Title,
+		Description: job.desc,
+		Company:     raw.Company,
+		Location:    raw.Location,
+		PostedAt:    nullableTime(raw.PostedAt),
+		ContentHash: job.hash,
+
+		Seniority:       job.class.Seniority,
+		ExperienceYears: job.class.ExperienceYears,
+		RoleFamily:      job.class.RoleFamily,
+		Facts:           job.facts,
+	}
+}
+
+// profileCandidates evaluates the filter rules for every profile and returns
+// the profiles whose stored outcome may change, with keyword scores filled in.
+// A profile a rule rejects is only returned when the rejecting decision is
+// new. For a tracked profile where reuse reports the stored score still
+// stands, the stored breakdown is reused instead of scoring again.
+func (s *IngestionService) profileCandidates(cycle ingestionCycle, fp store.JobFingerprint, job *pipelineJob, reuse func(*profileCandidate) bool) []profileCandidate {
+	var candidates []profileCandidate
+	for _, profile := range cycle.profiles {
+		c := profileCandidate{profile: profile}
+		c.stored, c.tracked = fp.Profiles[profile.ID]
+		c.decision = cycle.rules.Evaluate(ruleJob(job, profile))
+		if c.decision.Rejected() {
+			if !c.tracked || !sameDecision(c.stored.Decision, storedDecision(c.decision)) {
+				candidates = append(candidates, c)
+			}
+			continue
+		}
+
+		if c.tracked && c.stored.Breakdown != nil && reuse != nil && reuse(&c) {
+			c.reuse()
+		} else {
+			c.keywords = keywordScore(profile, job.raw.Title, job.desc)
+			c.needsAI = c.keywords.Score > 0 || c.decision.Accepted()
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+// reuse takes the keyword score and the score before boosts from the stored
+// breakdown.
+func (c *profileCandidate) reuse() {
+	b := c.stored.Breakdown
+	c.reused = true
+	c.keywords = c.stored.Keywords
+	c.score, c.summary = b.RuleScore, b.Summary
+	if b.AIScore != nil {
+		c.score = blendScore(b.RuleScore, *b.AIScore)
+	}
+}
+
+// unchanged reports whether m holds nothing the profile does not store already.
+func (c *profileCandidate) unchanged(m store.ProfileMatch) bool {
+	b := c.stored.Breakdown
+	return b != nil &&
+		b.Score == m.MatchScore &&
+		c.stored.Matched == (m.Matched || c.stored.Triaged) &&
+		sameDecision(c.stored.Decision, m.Decision)
+}
+
+// applyMatch blends the keyword score with the AI result, falling back to the
+// keyword score alone when the AI call failed. A job skipped for the AI
+// budget is not retried; it keeps no model, so a stale-only rescore picks it
+// up later.
+func applyMatch(c *profileCandidate, res MatchResult) {
+	if errors.Is(res.Err, ErrAIBudgetExhausted) {
+		c.score, c.summary = c.keywords.Score, "Rule-based match only (AI budget exhausted)"
+		return
+	}
+	if res.Err != nil {
+		observability.IncError(observability.ErrorAI, "ingestion")
+		slog.Warn("ingestion ai match failed", "error", res.Err)
+		c.score, c.summary = c.keywords.Score, "Rule-based match only"
+		c.aiFailed = true
+		return
+	}
+	c.match = res.Match
+	c.score = blendScore(c.keywords.Score, res.Match.MatchScore)
+	c.summary = res.Match.ShortSummary
+}
+
+// The keyword score and the AI score are blended 40/60.
+const (
+	ruleWeight = 0.4
+	aiWeight   = 0.6
+)
+
+func blendScore(keyword, ai int) int {
+	return int(float64(keyword)*ruleWeight + float64(ai)*aiWeight)
+}
+
+// profileMatch turns a scored candidate into the stored match, with a
+// breakdown of how score (after the rule boost) came about.
+func (c *profileCandidate) profileMatch(score, boost int, model string) store.ProfileMatch {
+	if c.reused {
+		breakdown := *c.stored.Breakdown
+		breakdown.Score, breakdown.Boost = score, boost
+		return store.ProfileMatch{
+			ProfileID:      c.profile.ID,
+			ProfileVersion: c.profile.Version,
+			MatchScore:     score,
+			MatchSummary:   c.summary,
+			Keywords:       c.keywords,
+			Model:          model,
+			Breakdown:      &breakdown,
+		}
+	}
+	breakdown := &store.ScoreBreakdown{
+		Score:        score,
+		RuleScore:    c.keywords.Score,
+		MatchedTerms: c.keywords.Terms,
+		AIFailed:     c.aiFailed,
+		Summary:      c.summary,
+		RuleWeight:   1,
+		Boost:        boost,
+		ScoredAt:     time.Now().UTC(),
+	}
+	if c.match != nil {
+		breakdown.AIScore = &c.match.MatchScore
+		breakdown.Strengths = c.match.Strengths
+		breakdown.Weaknesses = c.match.Weaknesses
+		breakdown.RuleWeight, breakdown.AIWeight = ruleWeight, aiWeight
+		breakdown.Model = model
+		breakdown.PromptVersion = c.match.PromptVersion
+	}
+	return store.ProfileMatch{
+		ProfileID:      c.profile.ID,
+		ProfileVersion: c.profile.Version,
+		MatchScore:     score,
+		MatchSummary:   c.summary,
+		Keywords:       c.keywords,
+		Model:          model,
+		Breakdown:      breakdown,
+	}
+}
+
+// finalizeMatches applies rule boosts and then the rules that read score.
+// Every candidate gets a match so its decision and fingerprint are stored;
+// jobs a rule rejects stay out of listings through Matched. Reused scores
+// whose outcome did not change are left out.
+func (s *IngestionService) finalizeMatches(ruleSet *rules.Set, job *pipelineJob) []store.ProfileMatch {
+	job.retryAI = nil
+	matches := make([]store.ProfileMatch, 0, len(job.candidates))
+	for i := range job.candidates {
+		c := &job.candidates[i]
+		if c.decision.Rejected() {
+			c.final = c.decision
+			matches = append(matches, store.ProfileMatch{
+				ProfileID:    c.profile.ID,
+				Decision:     storedDecision(c.decision),
+				RuleRejected: true,
+			})
+			continue
+		}
+
+		score, boost := c.score, 0
+		if score > 0 || c.decision.Accepted() {
+			boost = c.decision.Boost
+			score = clampMatchScore(score + boost)
+		}
+		scored := ruleJob(job, c.profile)
+		scored.Score = score
+		c.final = ruleSet.EvaluateScore(scored, c.decision)
+
+		var model string
+		switch {
+		case c.reused:
+			model = c.stored.Model
+		case c.aiFailed:
+			job.retryAI = append(job.retryAI, c.profile.ID)
+		case c.match != nil:
+			model = c.match.Model
+		}
+		m := c.profileMatch(score, boost, model)
+		m.Decision = storedDecision(c.final)
+		m.Matched = !c.final.Rejected()
+		if c.reused && c.unchanged(m) {
+			continue
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// matched reports whether any profile's match was not rejected by a rule.
+func (job *pipelineJob) matched() bool {
+	return slices.ContainsFunc(job.matches, func(m store.ProfileMatch) bool { return m.Matched })
+}
+
+// fanOut runs fn on n goroutines and closes out once all of them returned.
+func fanOut[T any](n int, out chan T, fn func()) {
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+}
+
+// send delivers v unless ctx is cancelled first.
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// batch groups items from in into slices of up to size, flushing a partial
+// batch after wait so a trickle of jobs is not held back. The output closes
+// when in closes (after a final flush) or ctx is cancelled. Once ctx is
+// cancelled, the items of the unsent batch and everything still arriving on
+// in are passed to discard.
+func batch[T any](ctx context.Context, in <-chan T, size int, wait time.Duration, discard func(T)) <-chan []T {
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		var buf []T
+		defer func() {
+			if ctx.Err() == nil {
+				return
+			}
+			for _, v := range buf {
+				discard(v)
+			}
+			for v := range in {
+				discard(v)
+			}
+		}()
+		timer := time.NewTimer(wait)
+		timer.Stop()
+		defer timer.Stop()
+
+		flush := func() bool {
+			if len(buf) == 0 {
+				return true
+			}
+			if !send(ctx, out, buf) {
+				return false
+			}
+			buf = nil
+			return true
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				if len(buf) == 0 {
+					timer.Reset(wait)
+				}
+				buf = append(buf, v)
+				if len(buf) >= size {
+					timer.Stop()
+					if !flush() {
+						return
+					}
+				}
+			case <-timer.C:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/baxromumarov/job-hunter/internal/ai"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// DefaultProfile is the Go/backend profile used until one is configured.
+func DefaultProfile() store.Profile {
+	return store.Profile{
+		Name:   store.DefaultProfileName,
+		Active: true,
+		ProfileSettings: store.ProfileSettings{
+			TechStack: []string{"golang", "backend", "grpc", "rest", "postgresql", "redis", "docker", "linux"},
+			IncludeKeywords: []string{
+				"golang",
+				"go developer",
+				"go engineer",
+				"backend",
+				"backend engineer",
+				"backend developer",
+				"platform engineer",
+				"infrastructure engineer",
+				"distributed systems",
+				"microservices",
+				"grpc",
+				"api",
+				"software engineer",
+				"site reliability",
+				"sre",
+			},
+			ExcludeKeywords: []string{
+				"sales",
+				"account executive",
+				"account manager",
+				"account director",
+				"business development",
+				"bdm",
+				"bdr",
+				"sdr",
+				"customer success",
+				"marketing",
+				"recruiter",
+				"talent acquisition",
+				"human resources",
+				"hr ",
+				"finance",
+				"accounting",
+				"legal",
+				"partnership",
+				"partnerships",
+				"salesforce",
+			},
+			Scoring: store.ScoringWeights{
+				Keywords: map[string]float64{
+					"golang":              3,
+					"go developer":        3,
+					"go engineer":         3,
+					"backend":             2,
+					"distributed systems": 2,
+					"grpc":                2,
+					"api":                 0.5,
+					"software engineer":   0.5,
+				},
+				Negative: map[string]float64{
+					"frontend": 2,
+					"ios":      2,
+					"android":  2,
+					"php":      1,
+				},
+			},
+			Location: store.LocationPolicy{
+				Blocked: []string{"india", "delhi", "mumbai", "bangalore", "bengaluru", "korea", "south korea", "seoul", "japan", "tokyo", "china", "beijing", "shanghai"},
+			},
+		},
+	}
+}
+
+// LoadProfileFile reads profiles from a JSON or YAML file (by its .yaml or
+// .yml extension) holding either a single profile or a list of them. YAML
+// uses the same field names as JSON. Fields missing from the file keep their
+// zero value; a missing name means the default profile.
+func LoadProfileFile(path string) ([]store.Profile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read profile file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if raw, err = yamlToJSON(raw); err != nil {
+			return nil, fmt.Errorf("parse profile file: %w", err)
+		}
+	}
+
+	var profiles []store.Profile
+	if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(raw, &profiles); err != nil {
+			return nil, fmt.Errorf("parse profile file: %w", err)
+		}
+	} else {
+		var p store.Profile
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("parse profile file: %w", err)
+		}
+		profiles = append(profiles, p)
+	}
+
+	for i := range profiles {
+		profiles[i].Active = true
+		normalized, err := NormalizeProfile(profiles[i])
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", profiles[i].Name, err)
+		}
+		profiles[i] = normalized
+	}
+	return profiles, nil
+}
+
+// yamlToJSON re-encodes a YAML document as JSON so profiles decode through
+// their JSON tags whatever the file format.
+func yamlToJSON(raw []byte) ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// NormalizeProfile lowercases and de-duplicates keyword lists and validates numeric bounds.
+func NormalizeProfile(p store.Profile) (store.Profile, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		p.Name = store.DefaultProfileName
+	}
+	if p.SalaryFloor < 0 {
+		return p, errors.New("salary_floor must not be negative")
+	}
+	if p.MaxExperienceYears < 0 {
+		return p, errors.New("max_experience_years must not be negative")
+	}
+	p.TechStack = normalizeTerms(p.TechStack)
+	p.IncludeKeywords = normalizeTerms(p.IncludeKeywords)
+	p.ExcludeKeywords = normalizeTerms(p.ExcludeKeywords)
+	p.Seniority = normalizeTerms(p.Seniority)
+	p.RoleFamilies = normalizeTerms(p.RoleFamilies)
+	if err := checkAllowed("seniority", p.Seniority, Seniorities); err != nil {
+		return p, err
+	}
+	if err := checkAllowed("role_families", p.RoleFamilies, RoleFamilies); err != nil {
+		return p, err
+	}
+	p.Location.Allowed = normalizeTerms(p.Location.Allowed)
+	p.Location.Blocked = normalizeTerms(p.Location.Blocked)
+	p.Preferences = strings.TrimSpace(p.Preferences)
+	if p.Scoring.TitleWeight < 0 || p.Scoring.DescriptionWeight < 0 {
+		return p, errors.New("scoring weights must not be negative")
+	}
+	var err error
+	if p.Scoring.Keywords, err = normalizeWeights(p.Scoring.Keywords); err != nil {
+		return p, err
+	}
+	if p.Scoring.Negative, err = normalizeWeights(p.Scoring.Negative); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func checkAllowed(field string, values, allowed []string) error {
+	for _, v := range values {
+		if !slices.Contains(allowed, strings.TrimSpace(v)) {
+			return fmt.Errorf("%s: unknown value %q (allowed: %s)", field, v, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// normalizeWeights lowercases weighted terms. Weights are magnitudes; negative
+// keywords subtract them, so a negative weight is rejected rather than guessed at.
+func normalizeWeights(weights map[string]float64) (map[string]float64, error) {
+	if len(weights) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]float64, len(weights))
+	for term, weight := range weights {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight for %q must not be negative", term)
+		}
+		out[term] = weight
+	}
+	return out, nil
+}
+
+// EnsureProfiles makes sure the default profile exists in the store and
+// moves legacy per-job scores into it. Profiles from the optional file are
+// applied on every start; the built-in default is only written when the file
+// did not provide one and none is stored yet.
+func EnsureProfiles(ctx context.Context, st *store.Store, path string) error {
+	if path != "" {
+		profiles, err := LoadProfileFile(path)
+		if err != nil {
+			return err
+		}
+		for _, p := range profiles {
+			saved, err := st.SaveProfile(ctx, p)
+			if err != nil {
+				return fmt.Errorf("save profile %q from file: %w", p.Name, err)
+			}
+			slog.Info("profile loaded from file", "path", path, "name", saved.Name, "version", saved.Version)
+		}
+	}
+
+	profile, err := st.GetProfile(ctx, store.DefaultProfileName)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		if profile, err = st.SaveProfile(ctx, DefaultProfile()); err != nil {
+			return fmt.Errorf("save default profile: %w", err)
+		}
+		slog.Info("default profile created", "version", profile.Version)
+	}
+
+	copied, err := st.MigrateLegacyJobState(ctx, profile.ID)
+	if err != nil {
+		return fmt.Errorf("migrate legacy job state: %w", err)
+	}
+	if copied > 0 {
+		slog.Info("legacy job scores moved to the default profile", "jobs", copied)
+	}
+	return nil
+}
+
+func candidateProfile(p store.Profile) ai.CandidateProfile {
+	return ai.CandidateProfile{
+		TechStack:   p.TechStack,
+		Keywords:    p.IncludeKeywords,
+		Seniority:   p.Seniority,
+		Locations:   p.Location.Allowed,
+		RemoteOnly:  p.Location.RemoteOnly,
+		SalaryFloor: p.SalaryFloor,
+		Preferences: p.Preferences,
+	}
+}
+
+func normalizeTerms(terms []string) []string {
+	seen := make(map[string]struct{}, len(terms))
+	out := make([]string, 0, len(terms))
+	for _, t := range terms {
+		// Keep trailing spaces: entries like "hr " rely on them to avoid partial-word hits.
+		t = strings.ToLower(strings.TrimLeft(t, " \t"))
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
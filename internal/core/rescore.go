@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/scraper"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// rescorePageSize is how many stored jobs a rescore loads per keyset page.
+const rescorePageSize = 100
+
+// ErrRescoreActive is returned by RunRescore while another rescore runs.
+var ErrRescoreActive = errors.New("a rescore is already running")
+
+// RescoreRequest selects the stored jobs and profiles a rescore recomputes.
+// Closed postings are never rescored.
+type RescoreRequest struct {
+	// Profile limits the rescore to one active profile; empty means all of them.
+	Profile  string    `json:"profile,omitempty"`
+	JobIDs   []int     `json:"job_ids,omitempty"`
+	SourceID int       `json:"source_id,omitempty"`
+	Since    time.Time `json:"since,omitempty"`
+	// StaleOnly only rescores scores a profile already holds that were
+	// computed with an older profile version or AI model. Other scores are
+	// kept and only the filter rules are applied to them again.
+	StaleOnly bool `json:"stale_only,omitempty"`
+	// Limit caps how many jobs are visited; 0 visits all that match.
+	Limit int `json:"limit,omitempty"`
+	// AIPerMinute caps AI calls; 0 uses RESCORE_AI_PER_MINUTE.
+	AIPerMinute int `json:"ai_per_minute,omitempty"`
+}
+
+// RescoreProgress counts what a rescore did so far.
+type RescoreProgress struct {
+	Jobs     int `json:"jobs"`
+	Rescored int `json:"rescored"`
+	Skipped  int `json:"skipped"`
+	AICalls  int `json:"ai_calls"`
+	AIFailed int `json:"ai_failed"`
+}
+
+// TriggerRescore starts a rescore in the background. If a rescore is already
+// active it is returned instead and created is false. It returns
+// store.ErrNotFound when req names a profile that is not active.
+func (s *IngestionService) TriggerRescore(ctx context.Context, req RescoreRequest) (*store.Run, bool, error) {
+	if err := s.checkRescoreProfile(ctx, req.Profile); err != nil {
+		return nil, false, err
+	}
+	run, created, err := s.store.CreateRun(ctx, store.RunKindRescore, 0, store.RunTriggerManual)
+	if err != nil || !created {
+		return run, false, err
+	}
+	s.goTracked(func() { s.executeRescore(s.runCtx, run, req, nil) })
+	return run, true, nil
+}
+
+// RunRescore runs a rescore in the foreground, recorded as a run like the
+// ones started through the API. onProgress is called after every job.
+func (s *IngestionService) RunRescore(ctx context.Context, req RescoreRequest, onProgress func(RescoreProgress)) (*store.Run, RescoreProgress, error) {
+	if err := s.checkRescoreProfile(ctx, req.Profile); err != nil {
+		return nil, RescoreProgress{}, err
+	}
+	run, created, err := s.store.CreateRun(ctx, store.RunKindRescore, 0, store.RunTriggerManual)
+	if err != nil {
+		return nil, RescoreProgress{}, err
+	}
+	if !created {
+		return run, RescoreProgress{}, ErrRescoreActive
+	}
+	progress, err := s.executeRescore(ctx, run, req, onProgress)
+	return run, progress, err
+}
+
+func (s *IngestionService) checkRescoreProfile(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, err := s.store.GetProfile(ctx, name)
+	if err != nil {
+		return err
+	}
+	if profile == nil || !profile.Active {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *IngestionService) executeRescore(ctx context.Context, run *store.Run, req RescoreRequest, onProgress func(RescoreProgress)) (RescoreProgress, error) {
+	tracker := NewRunTracker(s.store, run)
+	tracker.Start(ctx)
+	progress, err := s.rescore(ctx, req, tracker, onProgress)
+	if err != nil && ctx.Err() == nil {
+		observability.IncError(observability.ErrorStore, "rescore")
+		slog.Error("rescore failed", "run_id", run.ID, "error", err)
+	}
+	slog.Info("rescore finished", "run_id", run.ID, "jobs", progress.Jobs, "rescored", progress.Rescored, "ai_calls", progress.AICalls, "ai_failed", progress.AIFailed)
+	tracker.Finish(ctx, err)
+	return progress, err
+}
+
+// rescore walks the selected jobs in id order and scores each one again
+// against the current profiles, filter rules and AI model.
+func (s *IngestionService) rescore(ctx context.Context, req RescoreRequest, tracker *RunTracker, onProgress func(RescoreProgress)) (RescoreProgress, error) {
+	var progress RescoreProgress
+	cycle, err := s.loadCycle(ctx)
+	if err != nil {
+		return progress, err
+	}
+	if req.Profile != "" {
+		cycle.profiles = slices.DeleteFunc(cycle.profiles, func(p store.Profile) bool { return p.Name != req.Profile })
+	}
+
+	perMinute := req.AIPerMinute
+	if perMinute <= 0 {
+		perMinute = s.rescoreAIPerMinute
+	}
+	limiter := rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), 1)
+
+	sel := store.JobSelection{JobIDs: req.JobIDs, SourceID: req.SourceID, Since: req.Since}
+	afterID := 0
+	for {
+		pageSize := rescorePageSize
+		if req.Limit > 0 {
+			pageSize = min(pageSize, req.Limit-progress.Jobs)
+		}
+		if pageSize <= 0 {
+			return progress, nil
+		}
+
+		jobs, err := s.store.ListJobsForRescore(ctx, sel, afterID, pageSize)
+		if err != nil || len(jobs) == 0 {
+			return progress, err
+		}
+		urls := make([]string, len(jobs))
+		for i, job := range jobs {
+			urls[i] = job.URL
+		}
+		known, err := s.store.GetJobFingerprints(ctx, urls)
+		if err != nil {
+			return progress, err
+		}
+
+		for _, stored := range jobs {
+			if err := s.rescoreJob(ctx, cycle, stored, known[stored.URL], req.StaleOnly, limiter, &progress, tracker); err != nil {
+				return progress, err
+			}
+			if onProgress != nil {
+				onProgress(progress)
+			}
+		}
+		afterID = jobs[len(jobs)-1].ID
+	}
+}
+
+// rescoreJob runs one stored job through the same enrich, filter and score
+// steps as a freshly scraped one and stores the result. Only cancellation and
+// an exhausted AI budget abort the rescore; other AI and store failures are
+// counted and skipped.
+func (s *IngestionService) rescoreJob(ctx context.Context, cycle ingestionCycle, stored store.Job, fp store.JobFingerprint, staleOnly bool, limiter *rate.Limiter, progress *RescoreProgress, tracker *RunTracker) error {
+	progress.Jobs++
+	tracker.Processed(1)
+
+	job := storedPipelineJob(stored)
+	var reuse func(*profileCandidate) bool
+	if staleOnly {
+		reuse = func(c *profileCandidate) bool { return !s.staleScore(c) }
+	}
+	job.candidates = s.profileCandidates(cycle, fp, job, reuse)
+	if staleOnly {
+		job.candidates = slices.DeleteFunc(job.candidates, func(c profileCandidate) bool { return !c.tracked })
+	}
+
+	for i := range job.candidates {
+		c := &job.candidates[i]
+		if !c.needsAI {
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		match, err := s.matcher.Match(ctx, job.raw.Title, job.desc, candidateProfile(c.profile))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrAIBudgetExhausted) {
+			return err
+		}
+		progress.AICalls++
+		if err != nil {
+			progress.AIFailed++
+			tracker.Error(fmt.Sprintf("job %d profile %s: %v", stored.ID, c.profile.Name, err))
+		}
+		applyMatch(c, MatchResult{Match: match, Err: err})
+	}
+
+	job.matches = s.finalizeMatches(cycle.rules, job)
+	if len(job.matches) == 0 {
+		progress.Skipped++
+		tracker.Skipped(1)
+		return nil
+	}
+	if _, err := s.store.SaveJobMatches(context.WithoutCancel(ctx), job.storeJob(), job.matches); err != nil {
+		observability.IncError(observability.ErrorStore, "rescore")
+		slog.Error("rescore save failed", "job_id", stored.ID, "error", err)
+		tracker.Error(fmt.Sprintf("job %d: %v", stored.ID, err))
+		return nil
+	}
+	s.enqueueScoreRetries(ctx, job)
+	if !job.matched() {
+		progress.Skipped++
+		tracker.Skipped(1)
+		return nil
+	}
+	progress.Rescored++
+	tracker.JobSaved()
+	return nil
+}
+
+// storedPipelineJob turns a stored posting back into a pipeline job that
+// can be classified, filtered and scored again.
+func storedPipelineJob(stored store.Job) *pipelineJob {
+	job := &pipelineJob{
+		run: &sourceRun{src: store.Source{ID: stored.SourceID, URL: stored.SourceURL, Type: stored.SourceType}},
+		raw: scraper.RawJob{
+			URL:         stored.URL,
+			Title:       stored.Title,
+			Description: stored.Description,
+			Company:     stored.Company,
+			Location:    stored.Location,
+		},
+		desc:      stored.Description,
+		hash:      stored.ContentHash,
+		seen:      stored.CreatedAt,
+		unchanged: true,
+	}
+	if stored.PostedAt != nil {
+		job.raw.PostedAt = *stored.PostedAt
+	}
+	job.class = classifyJob(job.raw.Title, job.desc)
+	return job
+}
+
+// staleScore reports whether the score a profile holds for the job was
+// computed with an older profile version or, for a job that goes to the AI,
+// another AI model.
+func (s *IngestionService) staleScore(c *profileCandidate) bool {
+	if c.stored.Version != c.profile.Version {
+		return true
+	}
+	needsAI := c.stored.Breakdown.RuleScore > 0 || c.decision.Accepted()
+	return needsAI && c.stored.Model != s.matcher.Model()
+}
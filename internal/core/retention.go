@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// retentionPolicyFromEnv reads the per-state retention windows in days; 0
+// keeps a state forever. Applied jobs are always kept.
+func retentionPolicyFromEnv() store.RetentionPolicy {
+	days := func(name string, fallback int) time.Duration {
+		return time.Duration(max(intFromEnv(name, fallback), 0)) * 24 * time.Hour
+	}
+	return store.RetentionPolicy{
+		Untouched: days("RETENTION_UNTOUCHED_DAYS", 30),
+		Closed:    days("RETENTION_CLOSED_DAYS", 14),
+		Rejected:  days("RETENTION_REJECTED_DAYS", 7),
+	}
+}
+
+// RetentionPolicy returns the windows the retention pass deletes jobs by.
+func (s *IngestionService) RetentionPolicy() store.RetentionPolicy {
+	return s.retention
+}
+
+// PreviewRetention lists what the next retention pass would delete.
+func (s *IngestionService) PreviewRetention(ctx context.Context, limit int) ([]store.ExpiredJob, map[string]int, error) {
+	return s.store.PreviewExpiredJobs(ctx, s.retention, limit)
+}
+
+// deleteExpiredJobs runs the retention policy and logs how many jobs it
+// deleted per state.
+func (s *IngestionService) deleteExpiredJobs(ctx context.Context) error {
+	counts, err := s.store.DeleteExpiredJobs(ctx, s.retention)
+	if err != nil {
+		observability.IncError(observability.ErrorStore, "retention")
+		slog.Error("retention delete failed", "error", err)
+		return err
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total > 0 {
+		slog.Info("retention removed expired jobs", "count", total,
+			"untouched", counts[store.RetentionUntouched],
+			"closed", counts[store.RetentionClosed],
+			"rejected", counts[store.RetentionRejected])
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/rules"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// loadRuleSet compiles the enabled filter rules. Rules that fail to compile
+// are logged and skipped.
+func loadRuleSet(ctx context.Context, st *store.Store) (*rules.Set, error) {
+	stored, err := st.ListFilterRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]rules.Definition, 0, len(stored))
+	for _, r := range stored {
+		defs = append(defs, RuleDefinition(r))
+	}
+	set, errs := rules.NewSet(defs)
+	for _, err := range errs {
+		slog.Warn("ingestion skipping invalid filter rule", "error", err)
+	}
+	return set, nil
+}
+
+// maxAgeDays is the oldest posting the default max-age rule keeps. Scrapers
+// are asked for postings from the same window.
+const maxAgeDays = 10
+
+// defaultRulePosition places the default rules after rules added with the
+// default position 0, so an accept rule still overrides them.
+const defaultRulePosition = 1000
+
+// DefaultRules are the built-in profile filters, expressed as filter rules so
+// every rejection records the rule that made it. minMatch seeds the
+// min-match-score threshold.
+func DefaultRules(minMatch int) []store.FilterRule {
+	defs := []store.FilterRule{
+		{Name: "max-age", Expression: "age_days > " + strconv.Itoa(maxAgeDays)},
+		{Name: "blocked-location", Expression: "not location_allowed"},
+		{Name: "profile-mismatch", Expression: "not profile_fit"},
+		{Name: "excluded-keyword", Expression: `excluded_keyword != ""`},
+		{Name: "min-match-score", Expression: "score < " + strconv.Itoa(minMatch)},
+	}
+	for i := range defs {
+		defs[i].Action = rules.ActionReject
+		defs[i].Position = defaultRulePosition + i
+		defs[i].Enabled = true
+	}
+	return defs
+}
+
+// EnsureDefaultRules creates the default rules that are missing by name.
+// JOB_MIN_MATCH_SCORE only applies when min-match-score is first created;
+// after that the rule itself is edited. Disable a default rule instead of
+// deleting it, or it is created again on the next start.
+func EnsureDefaultRules(ctx context.Context, st *store.Store) error {
+	existing, err := st.ListFilterRules(ctx, false)
+	if err != nil {
+		return err
+	}
+	minMatch := clampMatchScore(intFromEnv("JOB_MIN_MATCH_SCORE", 60))
+	for _, def := range DefaultRules(minMatch) {
+		if slices.ContainsFunc(existing, func(r store.FilterRule) bool { return r.Name == def.Name }) {
+			continue
+		}
+		if _, err := st.CreateFilterRule(ctx, def); err != nil {
+			return fmt.Errorf("create default rule %q: %w", def.Name, err)
+		}
+		slog.Info("default filter rule created", "name", def.Name, "expression", def.Expression)
+	}
+	return nil
+}
+
+// RuleDefinition converts a stored rule into its compilable form.
+func RuleDefinition(r store.FilterRule) rules.Definition {
+	return rules.Definition{
+		ID:         r.ID,
+		Name:       r.Name,
+		Expression: r.Expression,
+		Action:     r.Action,
+		Boost:      r.Boost,
+	}
+}
+
+// ruleJob is the view of a job the filter rules see when evaluating it for
+// profile. Score is left for the caller to fill in after scoring.
+func ruleJob(job *pipelineJob, profile store.Profile) rules.Job {
+	raw, desc := job.raw, job.desc
+	return rules.Job{
+		Title:       raw.Title,
+		Company:     raw.Company,
+		Location:    raw.Location,
+		Description: desc,
+		SourceType:  job.run.src.Type,
+		URL:         raw.URL,
+		Salary:      estimateSalary(raw.Title + " " + desc),
+		Remote:      isRemote(raw.Title, raw.Location),
+
+		Seniority:       job.class.Seniority,
+		RoleFamily:      job.class.RoleFamily,
+		ExperienceYears: job.class.ExperienceYears,
+		AgeDays:         ageDays(raw.PostedAt, job.seen),
+
+		Profile:         profile.Name,
+		LocationAllowed: !isBlockedLocation(profile, raw.Location),
+		ProfileFit:      classFits(profile, job.class),
+		ExcludedKeyword: excludedKeyword(profile, raw.Title, desc),
+	}
+}
+
+// ageDays is how many whole days passed between posted and seen, or 0 when
+// either is unknown.
+func ageDays(posted, seen time.Time) int {
+	if posted.IsZero() || seen.IsZero() || seen.Before(posted) {
+		return 0
+	}
+	return int(seen.Sub(posted).Hours() / 24)
+}
+
+func storedDecision(d rules.Decision) *store.RuleDecision {
+	if d.Action == "" {
+		return nil
+	}
+	return &store.RuleDecision{
+		Action:   d.Action,
+		RuleID:   d.RuleID,
+		RuleName: d.RuleName,
+		Boost:    d.Boost,
+		Boosts:   d.Boosts,
+	}
+}
+
+func sameDecision(a, b *store.RuleDecision) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Action == b.Action &&
+		a.RuleID == b.RuleID &&
+		a.RuleName == b.RuleName &&
+		a.Boost == b.Boost &&
+		slices.Equal(a.Boosts, b.Boosts)
+}
+
+func isRemote(title, location string) bool {
+	text := strings.ToLower(title + " " + location)
+	return strings.Contains(text, "remote") || strings.Contains(text, "anywhere")
+}
+
+var salaryPattern = regexp.MustCompile(`(?i)(?:[$€£]\s?(\d{2,3})(?:[,.]?(\d{3}))?\s?(k)?)|(?:(\d{2,3})(?:[,.](\d{3}))?\s?(k)?\s?(?:usd|eur|gbp))`)
+
+// estimateSalary returns the highest annual figure mentioned in the text, or 0.
+// It understands "$120k", "$120,000" and "90k EUR" style amounts.
+func estimateSalary(text string) int {
+	best := 0
+	for _, m := range salaryPattern.FindAllStringSubmatch(text, -1) {
+		whole, thousands, k := m[1], m[2], m[3]
+		if whole == "" {
+			whole, thousands, k = m[4], m[5], m[6]
+		}
+		n, err := strconv.Atoi(whole)
+		if err != nil {
+			continue
+		}
+		switch {
+		case thousands != "":
+			n = n*1000 + atoiOrZero(thousands)
+		case k != "":
+			n *= 1000
+		default:
+			// A bare "$120" is more likely an hourly rate than a salary.
+			continue
+		}
+		best = max(best, n)
+	}
+	return best
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// maxRunErrors caps how many error messages a run keeps.
+const maxRunErrors = 20
+
+// RunTracker counts a run's progress and writes it to the store every few
+// seconds while the run executes, so GET /runs/{id} shows live numbers. The
+// counting methods are no-ops on a nil tracker.
+type RunTracker struct {
+	store *store.Store
+	id    int64
+
+	processed  atomic.Int64
+	skipped    atomic.Int64
+	jobsSaved  atomic.Int64
+	errorCount atomic.Int64
+
+	mu     sync.Mutex
+	errors []string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewRunTracker(st *store.Store, run *store.Run) *RunTracker {
+	return &RunTracker{
+		store: st,
+		id:    run.ID,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start marks the run as running and begins periodic progress writes.
+func (t *RunTracker) Start(ctx context.Context) {
+	if err := t.store.StartRun(ctx, t.id); err != nil {
+		slog.Error("run start failed", "run_id", t.id, "error", err)
+	}
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := t.store.UpdateRun(ctx, t.id, store.RunRunning, t.Progress()); err != nil {
+					slog.Error("run progress update failed", "run_id", t.id, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (t *RunTracker) Processed(n int) {
+	if t != nil {
+		t.processed.Add(int64(n))
+	}
+}
+
+func (t *RunTracker) Skipped(n int) {
+	if t != nil {
+		t.skipped.Add(int64(n))
+	}
+}
+
+func (t *RunTracker) JobSaved() {
+	if t != nil {
+		t.jobsSaved.Add(1)
+	}
+}
+
+// Error records a failure that did not abort the run.
+func (t *RunTracker) Error(msg string) {
+	if t == nil {
+		return
+	}
+	t.errorCount.Add(1)
+	t.mu.Lock()
+	if len(t.errors) < maxRunErrors {
+		t.errors = append(t.errors, msg)
+	}
+	t.mu.Unlock()
+}
+
+func (t *RunTracker) Progress() store.RunProgress {
+	t.mu.Lock()
+	errs := append([]string(nil), t.errors...)
+	t.mu.Unlock()
+	return store.RunProgress{
+		Processed:  int(t.processed.Load()),
+		Skipped:    int(t.skipped.Load()),
+		JobsSaved:  int(t.jobsSaved.Load()),
+		ErrorCount: int(t.errorCount.Load()),
+		Errors:     errs,
+	}
+}
+
+// Finish stops progress writes and stores the final state: cancelled when ctx
+// was cancelled, failed when err is set, succeeded otherwise.
+func (t *RunTracker) Finish(ctx context.Context, err error) {
+	close(t.stop)
+	<-t.done
+
+	status := store.RunSucceeded
+	switch {
+	case ctx.Err() != nil || errors.Is(err, context.Canceled):
+		status = store.RunCancelled
+	case err != nil:
+		status = store.RunFailed
+		t.Error(err.Error())
+	}
+
+	// The run context may already be cancelled; the final write must still land.
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := t.store.UpdateRun(writeCtx, t.id, status, t.Progress()); err != nil {
+		slog.Error("run finish failed", "run_id", t.id, "error", err)
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// scrapePolicy turns a source's scrape history into its next scrape time.
+// Sources that keep producing new jobs are scraped more often, quiet ones
+// drift towards max, and failing ones back off exponentially from min.
+type scrapePolicy struct {
+	base time.Duration
+	min  time.Duration
+	max  time.Duration
+}
+
+func scrapePolicyFromEnv() scrapePolicy {
+	p := scrapePolicy{
+		base: time.Duration(intFromEnv("SCRAPE_BASE_INTERVAL_MINUTES", 30)) * time.Minute,
+		min:  time.Duration(intFromEnv("SCRAPE_MIN_INTERVAL_MINUTES", 15)) * time.Minute,
+		max:  time.Duration(intFromEnv("SCRAPE_MAX_INTERVAL_MINUTES", 24*60)) * time.Minute,
+	}
+	if p.min <= 0 {
+		p.min = time.Minute
+	}
+	if p.max < p.min {
+		p.max = p.min
+	}
+	p.base = p.clamp(p.base)
+	return p
+}
+
+func (p scrapePolicy) clamp(d time.Duration) time.Duration {
+	return min(max(d, p.min), p.max)
+}
+
+// next computes the schedule after a scrape. newJobs is the number of
+// postings that were not stored before; failed marks a fetch error.
+func (p scrapePolicy) next(src store.Source, newJobs int, failed bool, now time.Time) store.SourceSchedule {
+	interval := p.base
+	if src.ScrapeIntervalMinutes > 0 {
+		interval = time.Duration(src.ScrapeIntervalMinutes) * time.Minute
+	}
+	schedule := store.SourceSchedule{
+		EmptyStreak: src.EmptyStreak,
+		LastNewJobs: newJobs,
+	}
+
+	switch {
+	case failed:
+		// Keep the learned interval; only delay the next attempt.
+		schedule.ErrorStreak = src.ErrorStreak + 1
+		schedule.LastNewJobs = src.LastNewJobs
+		backoff := p.clamp(p.min << min(schedule.ErrorStreak-1, 16))
+		schedule.IntervalMinutes = int(interval / time.Minute)
+		schedule.NextScrapeAt = now.Add(backoff)
+		return schedule
+	case newJobs > 0:
+		schedule.EmptyStreak = 0
+		interval = interval * 2 / 3
+	default:
+		schedule.EmptyStreak++
+		interval = interval * 3 / 2
+	}
+
+	interval = p.clamp(interval)
+	schedule.IntervalMinutes = int(interval / time.Minute)
+	if src.IntervalOverrideMinutes > 0 {
+		interval = time.Duration(src.IntervalOverrideMinutes) * time.Minute
+	}
+	schedule.NextScrapeAt = now.Add(interval)
+	return schedule
+}
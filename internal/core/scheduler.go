@@ -2,47 +2,198 @@ package core
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/baxromumarov/job-hunter/internal/observability"
 	"github.com/baxromumarov/job-hunter/internal/store"
 )
 
-type SchedulerService struct {
+// ScheduledJob is a named piece of background work run on a schedule.
+type ScheduledJob struct {
+	// Name identifies the job in /schedules and names the lease that keeps it
+	// on one replica.
+	Name string
+	// Spec is the default schedule: "@every <duration>", @hourly, @daily,
+	// @weekly, @monthly or five cron fields. SCHEDULE_<NAME> overrides it.
+	Spec string
+	// Jitter delays every run by a random amount up to it, so jobs do not
+	// fire in lockstep. SCHEDULE_<NAME>_JITTER overrides it.
+	Jitter time.Duration
+	// RunOnStart runs the job as soon as this replica takes its lease.
+	RunOnStart bool
+	Run        func(ctx context.Context) error
+}
+
+type scheduledJob struct {
+	ScheduledJob
+	schedule schedule
+	// paused is the state runOnce last saw; only its loop reads or writes it.
+	paused bool
+}
+
+// Scheduler runs the registered jobs. Every replica runs one, but each job
+// only runs on the replica holding its lease. A job's next run is computed
+// when the previous one finishes, so runs of one job never overlap; missed
+// ticks are skipped rather than queued.
+type Scheduler struct {
 	store *store.Store
+	jobs  []*scheduledJob
+	wg    sync.WaitGroup
 }
 
-func NewSchedulerService(store *store.Store) *SchedulerService {
-	return &SchedulerService{store: store}
+func NewScheduler(st *store.Store) *Scheduler {
+	return &Scheduler{store: st}
 }
 
-func (s *SchedulerService) Start(ctx context.Context) {
-	go s.runRetentionPolicy(ctx)
+// Register adds a job, applying the SCHEDULE_<NAME> and SCHEDULE_<NAME>_JITTER
+// overrides. It must be called before Start.
+func (s *Scheduler) Register(job ScheduledJob) error {
+	env := "SCHEDULE_" + strings.ToUpper(job.Name)
+	if spec := os.Getenv(env); spec != "" {
+		job.Spec = spec
+	}
+	if v := os.Getenv(env + "_JITTER"); v != "" {
+		jitter, err := time.ParseDuration(v)
+		if err != nil || jitter < 0 {
+			return fmt.Errorf("%s_JITTER: invalid duration %q", env, v)
+		}
+		job.Jitter = jitter
+	}
+	sched, err := parseSchedule(job.Spec)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", job.Name, err)
+	}
+	s.jobs = append(s.jobs, &scheduledJob{ScheduledJob: job, schedule: sched})
+	return nil
 }
 
-// runRetentionPolicy deletes jobs older than 30 days
-func (s *SchedulerService) runRetentionPolicy(ctx context.Context) {
-	ticker := time.NewTicker(24 * time.Hour) // Run once a day
-	defer ticker.Stop()
+// Start records the registered jobs and runs each one under its lease until
+// ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		if err := s.store.UpsertSchedule(ctx, job.Name, job.Spec, job.Jitter); err != nil {
+			observability.IncError(observability.ErrorStore, "scheduler")
+			slog.Error("schedule register failed", "schedule", job.Name, "error", err)
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			RunWhileLeader(ctx, s.store, job.Name, func(ctx context.Context) { s.loop(ctx, job) })
+		}()
+	}
+}
+
+// Wait blocks until every job has returned, or until ctx is done.
+func (s *Scheduler) Wait(ctx context.Context) error {
+	return WaitGroup(ctx, &s.wg)
+}
+
+// List returns the stored state of every schedule.
+func (s *Scheduler) List(ctx context.Context) ([]store.Schedule, error) {
+	return s.store.ListSchedules(ctx)
+}
+
+// SetPaused pauses or resumes a job on every replica. It returns
+// store.ErrNotFound for unknown names.
+func (s *Scheduler) SetPaused(ctx context.Context, name string, paused bool) (*store.Schedule, error) {
+	sc, err := s.store.SetSchedulePaused(ctx, name, paused)
+	if err == nil {
+		slog.Info("schedule paused changed", "schedule", name, "paused", paused)
+	}
+	return sc, err
+}
 
-	// Run immediately on startup
-	s.cleanup()
+// loop runs one job while this replica leads it. It stops scheduling once
+// intake closes; a run already started keeps ctx and finishes.
+func (s *Scheduler) loop(ctx context.Context, job *scheduledJob) {
+	intake, cancel := IntakeContext(ctx)
+	defer cancel()
 
+	next := time.Now()
+	if !job.RunOnStart {
+		next = s.nextRun(job, next)
+	}
 	for {
+		if err := s.store.SetScheduleNextRun(ctx, job.Name, next); err != nil && ctx.Err() == nil {
+			observability.IncError(observability.ErrorStore, "scheduler")
+			slog.Error("schedule update failed", "schedule", job.Name, "error", err)
+		}
+
+		timer := time.NewTimer(time.Until(next))
 		select {
-		case <-ctx.Done():
+		case <-intake.Done():
+			timer.Stop()
 			return
-		case <-ticker.C:
-			s.cleanup()
+		case <-timer.C:
 		}
+
+		s.runOnce(ctx, job)
+		if !IntakeOpen(ctx) {
+			return
+		}
+		next = s.nextRun(job, time.Now())
+	}
+}
+
+func (s *Scheduler) nextRun(job *scheduledJob, after time.Time) time.Time {
+	next := job.schedule.Next(after)
+	if job.Jitter > 0 {
+		next = next.Add(rand.N(job.Jitter))
 	}
+	return next
 }
 
-func (s *SchedulerService) cleanup() {
-	count, err := s.store.DeleteOldJobs(context.Background(), 30*24*time.Hour)
+// runOnce runs the job unless it is paused and records the outcome.
+func (s *Scheduler) runOnce(ctx context.Context, job *scheduledJob) {
+	sc, err := s.store.GetSchedule(ctx, job.Name)
 	if err != nil {
-		log.Printf("Retention Policy: Failed to cleanup old jobs: %v", err)
-	} else {
-		log.Printf("Retention Policy: Deleted %d old jobs", count)
+		observability.IncError(observability.ErrorStore, "scheduler")
+		slog.Error("schedule load failed", "schedule", job.Name, "error", err)
+		return
+	}
+	paused := sc != nil && sc.Paused
+	if paused != job.paused {
+		job.paused = paused
+		if paused {
+			slog.Info("schedule skip runs", "schedule", job.Name, "reason", "paused")
+		} else {
+			slog.Info("schedule resume runs", "schedule", job.Name)
+		}
+	}
+	if paused {
+		return
+	}
+
+	if err := s.store.MarkScheduleStarted(ctx, job.Name); err != nil {
+		observability.IncError(observability.ErrorStore, "scheduler")
+		slog.Error("schedule update failed", "schedule", job.Name, "error", err)
+	}
+	start := time.Now()
+	err = job.Run(ctx)
+	duration := time.Since(start)
+
+	status, message := store.ScheduleSucceeded, ""
+	switch {
+	case ctx.Err() != nil || errors.Is(err, context.Canceled):
+		status = store.ScheduleCancelled
+	case err != nil:
+		status, message = store.ScheduleFailed, err.Error()
+		slog.Error("schedule run failed", "schedule", job.Name, "duration", duration, "error", err)
+	}
+
+	// The run context may be cancelled by shutdown or a lost lease; the
+	// bookkeeping must still land.
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.store.MarkScheduleFinished(writeCtx, job.Name, status, duration, message); err != nil {
+		observability.IncError(observability.ErrorStore, "scheduler")
+		slog.Error("schedule update failed", "schedule", job.Name, "error", err)
 	}
 }
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+const (
+	defaultTitleWeight       = 2.0
+	defaultDescriptionWeight = 1.0
+	defaultTermWeight        = 1.0
+
+	// Any positive total scores at least keywordFloor, and keywordSaturation
+	// sets how fast the rest approaches 100. They keep the old hit-count
+	// scale, so the default JOB_MIN_MATCH_SCORE of 60 still passes a single
+	// description hit: 1 point scores 60, 2 (a title hit) 73, 3 82, 6 95.
+	keywordFloor      = 40.0
+	keywordSaturation = 2.466
+)
+
+var termPatterns sync.Map // term -> *regexp.Regexp
+
+// termPattern matches a term only on word boundaries, so "api" does not hit
+// "rapid". Boundaries are any non-alphanumeric rune, which keeps terms such as
+// "c++" or ".net" working where \b would not.
+func termPattern(term string) *regexp.Regexp {
+	if re, ok := termPatterns.Load(term); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(`(?i)(?:^|[^\pL\pN])` + regexp.QuoteMeta(strings.TrimSpace(term)) + `(?:$|[^\pL\pN])`)
+	termPatterns.Store(term, re)
+	return re
+}
+
+func containsTerm(text, term string) bool {
+	return termPattern(term).MatchString(text)
+}
+
+// keywordScore scores a job against the profile's weighted keywords. Each term
+// counts at most once per field: a hit earns the term weight times the field
+// weight, negative keywords subtract the same way, and the raw total is mapped
+// onto 0-100 with a saturating curve.
+func keywordScore(profile store.Profile, title, description string) store.KeywordBreakdown {
+	titleWeight := positiveOr(profile.Scoring.TitleWeight, defaultTitleWeight)
+	descWeight := positiveOr(profile.Scoring.DescriptionWeight, defaultDescriptionWeight)
+
+	var breakdown store.KeywordBreakdown
+	score := func(term string, weight float64) {
+		if containsTerm(title, term) {
+			breakdown.Terms = append(breakdown.Terms, store.TermHit{Term: term, Field: "title", Points: weight * titleWeight})
+			breakdown.Raw += weight * titleWeight
+		}
+		if containsTerm(description, term) {
+			breakdown.Terms = append(breakdown.Terms, store.TermHit{Term: term, Field: "description", Points: weight * descWeight})
+			breakdown.Raw += weight * descWeight
+		}
+	}
+
+	for _, term := range positiveTerms(profile) {
+		score(term, positiveOr(profile.Scoring.Keywords[term], defaultTermWeight))
+	}
+	for _, term := range sortedKeys(profile.Scoring.Negative) {
+		score(term, -positiveOr(profile.Scoring.Negative[term], defaultTermWeight))
+	}
+
+	breakdown.Raw = math.Round(breakdown.Raw*100) / 100
+	if breakdown.Raw > 0 {
+		breakdown.Score = int(math.Round(keywordFloor + (100-keywordFloor)*(1-math.Exp(-breakdown.Raw/keywordSaturation))))
+	}
+	return breakdown
+}
+
+// positiveTerms returns the include keywords followed by any weighted terms
+// that are not already listed there.
+func positiveTerms(profile store.Profile) []string {
+	terms := append([]string(nil), profile.IncludeKeywords...)
+	for _, term := range sortedKeys(profile.Scoring.Keywords) {
+		if !slices.Contains(profile.IncludeKeywords, term) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func positiveOr(v, fallback float64) float64 {
+	if v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// Shutdown runs in two steps. Closing intake stops the loops from taking new
+// work (scheduler ticks, task claims, discovery phases) while the work they
+// already took keeps its context and finishes; the context itself is only
+// cancelled once the drain deadline has passed.
+
+type intakeKey struct{}
+
+// WithIntake returns a copy of ctx whose loops stop taking new work once
+// intake is done. ctx stays live for the work already taken.
+func WithIntake(ctx, intake context.Context) context.Context {
+	return context.WithValue(ctx, intakeKey{}, intake)
+}
+
+// IntakeContext returns a context that is done once ctx stops taking new
+// work: when its intake closes or when ctx itself is done. Loops select on it
+// while waiting for their next piece of work.
+func IntakeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	intakeCtx, cancel := context.WithCancel(ctx)
+	intake, ok := ctx.Value(intakeKey{}).(context.Context)
+	if !ok {
+		return intakeCtx, cancel
+	}
+	stop := context.AfterFunc(intake, cancel)
+	return intakeCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// IntakeOpen reports whether ctx still takes new work.
+func IntakeOpen(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	intake, ok := ctx.Value(intakeKey{}).(context.Context)
+	return !ok || intake.Err() == nil
+}
+
+// WaitGroup waits for wg, giving up when ctx is done.
+func WaitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
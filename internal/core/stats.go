@@ -0,0 +1,18 @@
+package core
+
+import (
+	"context"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// SaveStatsSnapshot appends the current counters and totals to the stats
+// history served by /stats/history.
+func SaveStatsSnapshot(ctx context.Context, st *store.Store) error {
+	sourcesTotal, jobsTotal, activeJobs, err := st.GetStatsCounts(ctx)
+	if err != nil {
+		return err
+	}
+	return st.SaveStatsSnapshot(ctx, observability.Snapshot(), sourcesTotal, jobsTotal, activeJobs)
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/observability"
+	"github.com/baxromumarov/job-hunter/internal/store"
+)
+
+// Failed tasks retry after taskBackoffMin, doubling per attempt up to
+// taskBackoffMax, until they run out of attempts and are dead-lettered.
+const (
+	taskBackoffMin   = 30 * time.Second
+	taskBackoffMax   = time.Hour
+	taskPollInterval = 5 * time.Second
+	taskVisibility   = 10 * time.Minute
+)
+
+// WorkerID identifies this process as the holder of the tasks it claims.
+var WorkerID = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}()
+
+type ScrapeSourcePayload struct {
+	SourceID int `json:"source_id"`
+}
+
+type ScoreJobPayload struct {
+	JobURL    string `json:"job_url"`
+	ProfileID int    `json:"profile_id"`
+}
+
+type VerifyJobPayload struct {
+	JobID int    `json:"job_id"`
+	URL   string `json:"url"`
+}
+
+func taskBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 8 {
+		return taskBackoffMax
+	}
+	return min(taskBackoffMin<<(attempts-1), taskBackoffMax)
+}
+
+// ClaimTasks claims up to limit ready tasks of one type for this process.
+// A non-empty key restricts the claim to that task. Nothing is claimed once
+// intake has closed.
+func ClaimTasks(ctx context.Context, st *store.Store, taskType, key string, limit int, visibility time.Duration) ([]store.Task, error) {
+	if !IntakeOpen(ctx) {
+		return nil, nil
+	}
+	return st.ClaimTasks(ctx, store.TaskClaim{
+		Types:      []string{taskType},
+		Key:        key,
+		Worker:     WorkerID,
+		Limit:      limit,
+		Visibility: visibility,
+	})
+}
+
+// FinishTask records the outcome of a claimed task. A nil err completes it;
+// an error schedules a retry with backoff or dead-letters it. Work interrupted
+// by shutdown is handed back without using up an attempt.
+func FinishTask(ctx context.Context, st *store.Store, task store.Task, err error) {
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	switch {
+	case err == nil:
+		if err := st.CompleteTask(writeCtx, task); err != nil {
+			logTaskWrite(task, "task complete failed", err)
+			return
+		}
+		observability.IncTaskOutcome(task.Type, "done")
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		ReleaseTask(writeCtx, st, task)
+	default:
+		dead, ferr := st.FailTask(writeCtx, task, err.Error(), time.Now().Add(taskBackoff(task.Attempts)))
+		if ferr != nil {
+			logTaskWrite(task, "task fail failed", ferr)
+			return
+		}
+		if dead {
+			observability.IncTaskOutcome(task.Type, "dead")
+			slog.Warn("task dead-lettered", "task_id", task.ID, "type", task.Type, "attempts", task.Attempts, "error", err)
+			return
+		}
+		observability.IncTaskOutcome(task.Type, "retried")
+	}
+}
+
+// ReleaseTask hands a claimed task back untouched, e.g. during shutdown.
+func ReleaseTask(ctx context.Context, st *store.Store, task store.Task) {
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := st.ReleaseTask(writeCtx, task); err != nil {
+		logTaskWrite(task, "task release failed", err)
+	}
+}
+
+// logTaskWrite logs a failed outcome write. A task whose visibility timeout ran
+// out now belongs to another claim; that is counted, not treated as a store
+// error.
+func logTaskWrite(task store.Task, msg string, err error) {
+	if errors.Is(err, store.ErrTaskLost) {
+		observability.IncTaskOutcome(task.Type, "lost")
+		slog.Warn("task lost", "task_id", task.ID, "type", task.Type, "attempts", task.Attempts)
+		return
+	}
+	observability.IncError(observability.ErrorStore, "tasks")
+	slog.Error(msg, "task_id", task.ID, "error", err)
+}
+
+// TaskHandler processes one task; a returned error schedules a retry.
+type TaskHandler func(ctx context.Context, task store.Task) error
+
+// TaskWorker polls the queue for the task types it has handlers for. Every
+// replica runs one; SKIP LOCKED claims keep them from sharing a task.
+type TaskWorker struct {
+	store       *store.Store
+	concurrency int
+	handlers    map[string]TaskHandler
+}
+
+func NewTaskWorker(st *store.Store, concurrency int) *TaskWorker {
+	return &TaskWorker{
+		store:       st,
+		concurrency: max(concurrency, 1),
+		handlers:    make(map[string]TaskHandler),
+	}
+}
+
+// Handle registers the handler for a task type. It must be called before Run.
+func (w *TaskWorker) Handle(taskType string, h TaskHandler) {
+	w.handlers[taskType] = h
+}
+
+// Run claims and processes tasks until intake closes. Tasks already claimed
+// finish on ctx.
+func (w *TaskWorker) Run(ctx context.Context) {
+	types := make([]string, 0, len(w.handlers))
+	for taskType := range w.handlers {
+		types = append(types, taskType)
+	}
+	intake, cancel := IntakeContext(ctx)
+	defer cancel()
+
+	for IntakeOpen(ctx) {
+		tasks, err := w.store.ClaimTasks(ctx, store.TaskClaim{
+			Types:      types,
+			Worker:     WorkerID,
+			Limit:      w.concurrency,
+			Visibility: taskVisibility,
+		})
+		if err != nil && ctx.Err() == nil {
+			observability.IncError(observability.ErrorStore, "tasks")
+			slog.Error("task claim failed", "error", err)
+		}
+		if len(tasks) == 0 {
+			select {
+			case <-intake.Done():
+			case <-time.After(taskPollInterval):
+			}
+			continue
+		}
+
+		var wg sync.WaitGroup
+		for _, task := range tasks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				FinishTask(ctx, w.store, task, w.handlers[task.Type](ctx, task))
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -3,8 +3,11 @@ package discovery
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "embed"
@@ -21,6 +24,15 @@ type Engine struct {
 	store      *store.Store
 	classifier *core.ClassifierService
 	fetcher    *httpx.CollyFetcher
+
+	// runCtx is the context passed to StartDiscovery; manual runs use it so
+	// they outlive the HTTP request that started them.
+	runCtx context.Context
+	// tracker counts the active run. Runs never overlap because the store
+	// allows one active discovery run at a time.
+	tracker atomic.Pointer[core.RunTracker]
+	// wg tracks manual runs so shutdown can wait for them.
+	wg sync.WaitGroup
 }
 
 type candidateSource struct {
@@ -40,6 +52,17 @@ var seedCandidates = loadSeedCandidates()
 
 const maxCandidateDepth = 2
 
+// aiAcceptConfidence is how sure the AI classifier must be to overturn a
+// heuristic rejection.
+const aiAcceptConfidence = 0.7
+
+// Candidates are claimed a few at a time; one that is not analyzed within
+// candidateVisibility is handed out again.
+const (
+	candidateClaimSize  = 5
+	candidateVisibility = 10 * time.Minute
+)
+
 func loadSeedCandidates() []candidateSource {
 	var seeds []candidateSource
 	if err := json.Unmarshal(seedsJSON, &seeds); err != nil {
@@ -54,45 +77,138 @@ func NewEngine(store *store.Store, classifier *core.ClassifierService) *Engine {
 		store:      store,
 		classifier: classifier,
 		fetcher:    httpx.NewCollyFetcher("job-hunter-bot/1.0"),
+		runCtx:     context.Background(),
 	}
 }
 
+// StartDiscovery remembers ctx for manual runs. Scheduled discovery is run by
+// the core.Scheduler through RunScheduled.
 func (e *Engine) StartDiscovery(ctx context.Context) {
 	slog.Info("discovery start")
+	e.runCtx = ctx
+}
 
-	go func() {
-		e.runCycle(ctx)
-		e.crawlForCareerLinks(ctx)
-		e.searchWeb(ctx)
+// RunScheduled runs a discovery cycle unless a manual run is still active.
+func (e *Engine) RunScheduled(ctx context.Context) error {
+	run, created, err := e.store.CreateRun(ctx, store.RunKindDiscovery, 0, store.RunTriggerSchedule)
+	if err != nil {
+		observability.IncError(observability.ErrorStore, "discovery")
+		return fmt.Errorf("create run: %w", err)
+	}
+	if !created {
+		slog.Info("discovery skip cycle", "reason", "run_active", "run_id", run.ID)
+		return nil
+	}
+	e.execute(ctx, run)
+	return nil
+}
 
-		ticker := time.NewTicker(1 * time.Hour)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				e.runCycle(ctx)
-				e.crawlForCareerLinks(ctx)
-				e.searchWeb(ctx)
-			}
-		}
+// TriggerRun starts a manual discovery run. If a discovery run is already
+// active it is returned instead and created is false.
+func (e *Engine) TriggerRun(ctx context.Context) (*store.Run, bool, error) {
+	run, created, err := e.store.CreateRun(ctx, store.RunKindDiscovery, 0, store.RunTriggerManual)
+	if err != nil || !created {
+		return run, false, err
+	}
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.execute(e.runCtx, run)
 	}()
+	return run, true, nil
+}
+
+// Wait blocks until every manual run has returned, or
+// until ctx is done, in which case it returns ctx.Err().
+func (e *Engine) Wait(ctx context.Context) error {
+	return core.WaitGroup(ctx, &e.wg)
+}
+
+func (e *Engine) execute(ctx context.Context, run *store.Run) {
+	tracker := core.NewRunTracker(e.store, run)
+	e.tracker.Store(tracker)
+	defer e.tracker.Store(nil)
+
+	tracker.Start(ctx)
+	// Each phase queues candidates; draining after every phase starts the
+	// analysis before the slower crawl and search phases finish.
+	e.runCycle(ctx)
+	e.drainCandidates(ctx)
+	e.crawlForCareerLinks(ctx)
+	e.drainCandidates(ctx)
+	e.searchWeb(ctx)
+	e.drainCandidates(ctx)
+	var err error
+	if !core.IntakeOpen(ctx) {
+		// The phases stop early once intake closes; the run is incomplete.
+		err = context.Canceled
+	}
+	tracker.Finish(ctx, err)
 }
 
 func (e *Engine) runCycle(ctx context.Context) {
 	for _, c := range seedCandidates {
-		select {
-		case <-ctx.Done():
+		if !core.IntakeOpen(ctx) {
 			return
-		default:
-			e.processCandidate(ctx, c)
-			time.Sleep(500 * time.Millisecond)
 		}
+		e.enqueueCandidate(ctx, c)
 	}
 	slog.Info("discovery cycle complete")
 }
 
+// enqueueCandidate queues a candidate for analysis. A URL already waiting in
+// the queue is not queued twice.
+func (e *Engine) enqueueCandidate(ctx context.Context, c candidateSource) {
+	key := c.URL
+	if normalized, _, err := urlutil.Normalize(c.URL); err == nil {
+		key = normalized
+	}
+	if _, _, err := e.store.EnqueueTask(ctx, store.NewTask{
+		Type:    store.TaskAnalyzeCandidate,
+		Key:     key,
+		Payload: c,
+	}); err != nil && ctx.Err() == nil {
+		observability.IncError(observability.ErrorStore, "discovery")
+		slog.Error("discovery enqueue candidate failed", "url", c.URL, "error", err)
+	}
+}
+
+// drainCandidates analyzes queued candidates until none is ready, including
+// the child pages queued along the way. Candidates left over by a crashed or
+// interrupted run are picked up here too.
+func (e *Engine) drainCandidates(ctx context.Context) {
+	for core.IntakeOpen(ctx) {
+		tasks, err := core.ClaimTasks(ctx, e.store, store.TaskAnalyzeCandidate, "", candidateClaimSize, candidateVisibility)
+		if err != nil {
+			if ctx.Err() == nil {
+				observability.IncError(observability.ErrorStore, "discovery")
+				slog.Error("discovery claim candidates failed", "error", err)
+			}
+			return
+		}
+		if len(tasks) == 0 {
+			return
+		}
+		for _, task := range tasks {
+			if !core.IntakeOpen(ctx) {
+				core.ReleaseTask(ctx, e.store, task)
+				continue
+			}
+			var c candidateSource
+			if err := json.Unmarshal(task.Payload, &c); err != nil {
+				core.FinishTask(ctx, e.store, task, fmt.Errorf("decode candidate: %w", err))
+				continue
+			}
+			core.FinishTask(ctx, e.store, task, e.processCandidate(ctx, c))
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+}
+
 func (e *Engine) crawlForCareerLinks(ctx context.Context) {
 	sites := []string{
 		"https://github.com",
@@ -111,10 +227,8 @@ func (e *Engine) crawlForCareerLinks(ctx context.Context) {
 
 	c := newCrawler()
 	for _, site := range sites {
-		select {
-		case <-ctx.Done():
+		if !core.IntakeOpen(ctx) {
 			return
-		default:
 		}
 		links := c.extractCareerLinks(ctx, site)
 		if containsATS(links) {
@@ -127,7 +241,7 @@ func (e *Engine) crawlForCareerLinks(ctx context.Context) {
 			}
 		}
 		for _, link := range links {
-			e.processCandidate(ctx, candidateSource{
+			e.enqueueCandidate(ctx, candidateSource{
 				URL:        link,
 				SourceType: guessSourceType(link),
 				ParentURL:  site,
@@ -151,6 +265,9 @@ func (e *Engine) searchWeb(ctx context.Context) {
 	seen := make(map[string]struct{})
 	c := newCrawler()
 	for _, q := range queries {
+		if !core.IntakeOpen(ctx) {
+			return
+		}
 		urls := duckDuckSearch(ctx, q, 15)
 		for _, u := range urls {
 			if _, ok := seen[u]; ok {
@@ -171,7 +288,7 @@ func (e *Engine) searchWeb(ctx context.Context) {
 				}
 			}
 			if !atsOnly && urlutil.IsDiscoveryEligible(u) {
-				e.processCandidate(ctx, candidateSource{
+				e.enqueueCandidate(ctx, candidateSource{
 					URL:        u,
 					SourceType: guessSourceType(u),
 				})
@@ -181,7 +298,7 @@ func (e *Engine) searchWeb(ctx context.Context) {
 					continue
 				}
 				seen[link] = struct{}{}
-				e.processCandidate(ctx, candidateSource{
+				e.enqueueCandidate(ctx, candidateSource{
 					URL:        link,
 					SourceType: guessSourceType(link),
 					ParentURL:  u,
@@ -192,11 +309,21 @@ func (e *Engine) searchWeb(ctx context.Context) {
 	}
 }
 
-func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
+// processCandidate classifies one candidate URL and records the outcome. Only
+// failures worth retrying (the page fetch, shutdown) are returned; everything
+// else is recorded on the source row.
+func (e *Engine) processCandidate(ctx context.Context, c candidateSource) error {
+	// Shutdown is honoured between candidates. Once a candidate starts, its
+	// store writes run detached so its sources are never left half-recorded.
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	writeCtx := context.WithoutCancel(ctx)
+
 	normalized, host, err := urlutil.Normalize(c.URL)
 	if err != nil {
 		slog.Info("discovery skip", "url", c.URL, "reason", "invalid_url")
-		return
+		return nil
 	}
 	if urlutil.IsATSHost(host) {
 		if atsURL, atsHost, err := urlutil.NormalizeATSLink(normalized); err == nil && atsHost != "" {
@@ -206,19 +333,20 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 	}
 	if !urlutil.IsDiscoveryEligible(normalized) {
 		slog.Info("discovery skip", "url", normalized, "reason", "ineligible")
-		return
+		return nil
 	}
 	if !urlutil.IsATSHost(host) {
-		atsBacked, err := e.store.IsHostATSBacked(ctx, host)
+		atsBacked, err := e.store.IsHostATSBacked(writeCtx, host)
 		if err != nil {
-			observability.IncError(observability.ErrorStore, "discovery")
-			slog.Error("discovery ATS-backed check failed", "url", normalized, "error", err)
-		} else if atsBacked {
+			return storeFailed("discovery ATS-backed check failed", normalized, err)
+		}
+		if atsBacked {
 			slog.Info("discovery skip", "url", normalized, "reason", "ats_backed")
-			return
+			return nil
 		}
 	}
 	observability.IncURLsDiscovered("discovery")
+	e.tracker.Load().Processed(1)
 
 	sourceType := c.SourceType
 	if sourceType == "" {
@@ -226,11 +354,9 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 	}
 	forcedJobBoard := urlutil.IsKnownJobBoardHost(host)
 
-	existing, err := e.store.FindSourceByURL(ctx, normalized)
+	existing, err := e.store.FindSourceByURL(writeCtx, normalized)
 	if err != nil {
-		observability.IncError(observability.ErrorStore, "discovery")
-		slog.Error("discovery lookup failed", "url", normalized, "error", err)
-		return
+		return storeFailed("discovery lookup failed", normalized, err)
 	}
 	retryAttempt := false
 	if existing != nil && existing.PageType != urlutil.PageTypeCandidate && !forcedJobBoard {
@@ -246,28 +372,28 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 		}
 		if !retryAttempt {
 			slog.Info("discovery skip", "url", normalized, "reason", reason, "page_type", existing.PageType)
-			return
+			return nil
 		}
 	}
 
 	if forcedJobBoard {
 		pageType := urlutil.PageTypeJobList
-		canonicalURL, isAlias, err := e.store.ResolveCanonicalSource(ctx, normalized, host, pageType)
+		canonicalURL, isAlias, err := e.store.ResolveCanonicalSource(writeCtx, normalized, host, pageType)
 		if err != nil {
-			observability.IncError(observability.ErrorStore, "discovery")
-			slog.Error("discovery canonical resolve failed", "url", normalized, "error", err)
-			return
+			return storeFailed("discovery canonical resolve failed", normalized, err)
 		}
 		if isAlias {
-			_, _, _ = e.store.AddSource(ctx, normalized, sourceType, pageType, true, canonicalURL, false, false, 0, "alias", false)
+			if _, _, err := e.store.AddSource(writeCtx, normalized, sourceType, pageType, true, canonicalURL, false, false, 0, "alias", false); err != nil {
+				return storeFailed("discovery store source failed", normalized, err)
+			}
 			slog.Info("discovery skip", "url", normalized, "reason", "alias", "canonical", canonicalURL)
-			return
+			return nil
 		}
 
 		observability.IncSourceDecision("accepted")
 		observability.IncSourcesPromoted("discovery")
 		id, existed, err := e.store.AddSource(
-			ctx,
+			writeCtx,
 			normalized,
 			sourceType,
 			pageType,
@@ -280,36 +406,34 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 			false,
 		)
 		if err != nil {
-			observability.IncError(observability.ErrorStore, "discovery")
-			slog.Error("discovery store source failed", "url", normalized, "error", err)
-			return
+			return storeFailed("discovery store source failed", normalized, err)
 		}
 		if existed {
 			slog.Info("discovery skip", "url", normalized, "reason", "already_processed", "id", id)
-			return
+			return nil
 		}
 		slog.Info("discovery source approved", "url", normalized, "id", id)
-		return
+		return nil
 	}
 
 	if urlutil.IsATSHost(host) {
 		pageType := urlutil.PageTypeJobList
-		canonicalURL, isAlias, err := e.store.ResolveCanonicalSource(ctx, normalized, host, pageType)
+		canonicalURL, isAlias, err := e.store.ResolveCanonicalSource(writeCtx, normalized, host, pageType)
 		if err != nil {
-			observability.IncError(observability.ErrorStore, "discovery")
-			slog.Error("discovery canonical resolve failed", "url", normalized, "error", err)
-			return
+			return storeFailed("discovery canonical resolve failed", normalized, err)
 		}
 		if isAlias {
-			_, _, _ = e.store.AddSource(ctx, normalized, sourceType, pageType, true, canonicalURL, false, false, 0, "alias", false)
+			if _, _, err := e.store.AddSource(writeCtx, normalized, sourceType, pageType, true, canonicalURL, false, false, 0, "alias", false); err != nil {
+				return storeFailed("discovery store source failed", normalized, err)
+			}
 			slog.Info("discovery skip", "url", normalized, "reason", "alias", "canonical", canonicalURL)
-			return
+			return nil
 		}
 
 		observability.IncSourceDecision("accepted")
 		observability.IncSourcesPromoted("discovery")
 		id, existed, err := e.store.AddSource(
-			ctx,
+			writeCtx,
 			normalized,
 			sourceType,
 			pageType,
@@ -322,20 +446,18 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 			false,
 		)
 		if err != nil {
-			observability.IncError(observability.ErrorStore, "discovery")
-			slog.Error("discovery store source failed", "url", normalized, "error", err)
-			return
+			return storeFailed("discovery store source failed", normalized, err)
 		}
 		if existed {
 			slog.Info("discovery skip", "url", normalized, "reason", "already_processed", "id", id)
-			return
+			return nil
 		}
 		slog.Info("discovery source approved", "url", normalized, "id", id)
-		return
+		return nil
 	}
 
-	_, _, _ = e.store.AddSource(
-		ctx,
+	if _, _, err := e.store.AddSource(
+		writeCtx,
 		normalized,
 		sourceType,
 		urlutil.PageTypeCandidate,
@@ -346,28 +468,31 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 		0,
 		"candidate",
 		false,
-	)
+	); err != nil {
+		return storeFailed("discovery store source failed", normalized, err)
+	}
 
 	signals, err := content.Analyze(ctx, e.fetcher, normalized)
 	if err != nil {
 		errType := observability.ClassifyFetchError(err)
 		observability.IncError(errType, "discovery")
-		_ = e.store.MarkSourceErrorByURL(ctx, normalized, errType, err.Error())
+		_ = e.store.MarkSourceErrorByURL(writeCtx, normalized, errType, err.Error())
 		slog.Error("discovery fetch failed", "url", normalized, "error", err)
-		return
+		e.tracker.Load().Error(normalized + ": " + err.Error())
+		return err
 	}
 	observability.IncPagesCrawled("discovery")
 
 	if len(signals.ATSLinks) > 0 {
 		observability.IncATSDetected("discovery")
-		e.addATSSources(ctx, signals.ATSLinks)
-		if err := e.store.MarkHostATSBacked(ctx, host); err != nil {
+		e.addATSSources(writeCtx, signals.ATSLinks)
+		if err := e.store.MarkHostATSBacked(writeCtx, host); err != nil {
 			observability.IncError(observability.ErrorStore, "discovery")
 			slog.Error("discovery ATS-backed mark failed", "url", normalized, "error", err)
 		}
 		observability.IncSourceDecision("rejected")
-		_, _, _ = e.store.AddSource(
-			ctx,
+		if _, _, err := e.store.AddSource(
+			writeCtx,
 			normalized,
 			sourceType,
 			urlutil.PageTypeNonJobHighConfidence,
@@ -378,13 +503,19 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 			0.9,
 			"ats_link",
 			true,
-		)
+		); err != nil {
+			return storeFailed("discovery store source failed", normalized, err)
+		}
 		slog.Info("discovery skip", "url", normalized, "reason", "ats_link", "ats_count", len(signals.ATSLinks))
-		return
+		return nil
 	}
 
 	// Fix #4: Use ClassifyWithLogging for debug output on rejected pages
 	decision := content.ClassifyWithLogging(normalized, signals)
+	aiModel := ""
+	if decision.PageType == urlutil.PageTypeNonJob && retryAttempt {
+		decision, aiModel = e.secondOpinion(ctx, normalized, signals, decision)
+	}
 	if decision.PageType == urlutil.PageTypeNonJob {
 		pageType := urlutil.PageTypeNonJobLowConfidence
 		reason := decision.Reason
@@ -392,15 +523,15 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 			pageType = urlutil.PageTypeNonJobHighConfidence
 			reason = decision.Reason + "_retry"
 			if existing != nil {
-				if err := e.store.IncrementSourceRecheck(ctx, existing.ID); err != nil {
+				if err := e.store.IncrementSourceRecheck(writeCtx, existing.ID); err != nil {
 					observability.IncError(observability.ErrorStore, "discovery")
 					slog.Error("discovery recheck increment failed", "source_id", existing.ID, "error", err)
 				}
 			}
 		}
 		observability.IncSourceDecision("rejected")
-		_, _, _ = e.store.AddSource(
-			ctx,
+		id, _, err := e.store.AddSource(
+			writeCtx,
 			normalized,
 			sourceType,
 			pageType,
@@ -412,6 +543,12 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 			reason,
 			false,
 		)
+		if err != nil {
+			return storeFailed("discovery store source failed", normalized, err)
+		}
+		if err := e.recordClassificationModel(writeCtx, id, aiModel); err != nil {
+			return storeFailed("discovery store classification model failed", normalized, err)
+		}
 		slog.Info("discovery skip", "url", normalized, "reason", "non_job", "classification", reason)
 		if pageType == urlutil.PageTypeNonJobLowConfidence {
 			e.discoverChildCandidates(ctx, candidateSource{
@@ -420,26 +557,26 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 				ParentURL: c.ParentURL,
 			})
 		}
-		return
+		return nil
 	}
 
-	canonicalURL, isAlias, err := e.store.ResolveCanonicalSource(ctx, normalized, host, decision.PageType)
+	canonicalURL, isAlias, err := e.store.ResolveCanonicalSource(writeCtx, normalized, host, decision.PageType)
 	if err != nil {
-		observability.IncError(observability.ErrorStore, "discovery")
-		slog.Error("discovery canonical resolve failed", "url", normalized, "error", err)
-		return
+		return storeFailed("discovery canonical resolve failed", normalized, err)
 	}
 	if isAlias {
-		_, _, _ = e.store.AddSource(ctx, normalized, sourceType, decision.PageType, true, canonicalURL, false, false, 0, "alias", false)
+		if _, _, err := e.store.AddSource(writeCtx, normalized, sourceType, decision.PageType, true, canonicalURL, false, false, 0, "alias", false); err != nil {
+			return storeFailed("discovery store source failed", normalized, err)
+		}
 		slog.Info("discovery skip", "url", normalized, "reason", "alias", "canonical", canonicalURL)
 		e.promoteParent(ctx, c.ParentURL, "child_"+decision.Reason, decision.Confidence)
-		return
+		return nil
 	}
 
 	observability.IncSourceDecision("accepted")
 	observability.IncSourcesPromoted("discovery")
 	id, existed, err := e.store.AddSource(
-		ctx,
+		writeCtx,
 		normalized,
 		sourceType,
 		decision.PageType,
@@ -452,17 +589,55 @@ func (e *Engine) processCandidate(ctx context.Context, c candidateSource) {
 		false,
 	)
 	if err != nil {
-		observability.IncError(observability.ErrorStore, "discovery")
-		slog.Error("discovery store source failed", "url", normalized, "error", err)
-		return
+		return storeFailed("discovery store source failed", normalized, err)
+	}
+	if err := e.recordClassificationModel(writeCtx, id, aiModel); err != nil {
+		return storeFailed("discovery store classification model failed", normalized, err)
 	}
 	if existed {
 		slog.Info("discovery skip", "url", normalized, "reason", "already_processed", "id", id)
-		return
+		return nil
 	}
 	slog.Info("discovery source approved", "url", normalized, "id", id)
 	e.promoteParent(ctx, c.ParentURL, "child_"+decision.Reason, decision.Confidence)
 	// Scraping now handled by ingestion using site-specific scrapers.
+	return nil
+}
+
+// secondOpinion asks the AI classifier about a page the heuristics rejected
+// again on its recheck. A tech job site it is sure about becomes a job list;
+// otherwise the rejection stands with the AI's confidence. model names the AI
+// model that decided, or is empty when the AI was not asked or failed.
+func (e *Engine) secondOpinion(ctx context.Context, url string, signals content.Signals, decision content.Decision) (_ content.Decision, model string) {
+	if e.classifier == nil || !e.classifier.Enabled() {
+		return decision, ""
+	}
+	class, err := e.classifier.Classify(ctx, url, signals.Title, signals.Meta, signals.Text)
+	if err != nil {
+		slog.Warn("discovery ai classification failed", "url", url, "error", err)
+		return decision, ""
+	}
+	if class.IsJobSite && class.TechRelated && class.Confidence >= aiAcceptConfidence {
+		return content.Decision{PageType: urlutil.PageTypeJobList, Reason: "ai_job_site", Confidence: class.Confidence}, class.Model
+	}
+	return content.Decision{PageType: urlutil.PageTypeNonJob, Reason: "ai_non_job", Confidence: class.Confidence}, class.Model
+}
+
+// recordClassificationModel saves the AI model behind a source's
+// classification. Heuristic classifications have none.
+func (e *Engine) recordClassificationModel(ctx context.Context, sourceID int, model string) error {
+	if model == "" {
+		return nil
+	}
+	return e.store.SetSourceClassificationModel(ctx, sourceID, model)
+}
+
+// storeFailed reports a store error hit while recording a candidate. It is
+// returned so the candidate task is retried instead of completed.
+func storeFailed(msg, url string, err error) error {
+	observability.IncError(observability.ErrorStore, "discovery")
+	slog.Error(msg, "url", url, "error", err)
+	return fmt.Errorf("%s: %w", msg, err)
 }
 
 func (e *Engine) addATSSources(ctx context.Context, links []string) {
@@ -512,7 +687,7 @@ func (e *Engine) discoverChildCandidates(ctx context.Context, parent candidateSo
 		if link == "" || link == parent.URL {
 			continue
 		}
-		e.processCandidate(ctx, candidateSource{
+		e.enqueueCandidate(ctx, candidateSource{
 			URL:        link,
 			SourceType: guessSourceType(link),
 			ParentURL:  parent.URL,
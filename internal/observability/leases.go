@@ -0,0 +1,46 @@
+package observability
+
+import (
+	"sync"
+	"time"
+)
+
+// LeaseStatus reports whether this replica currently leads a background loop.
+type LeaseStatus struct {
+	Held  bool       `json:"held"`
+	Since *time.Time `json:"since,omitempty"`
+	// Changes counts how often this replica gained or lost the lease.
+	Changes uint64 `json:"changes"`
+}
+
+var (
+	leaseMu sync.Mutex
+	leases  = map[string]LeaseStatus{}
+)
+
+// SetLeaseHeld records that this replica gained (held=true) or lost a lease.
+func SetLeaseHeld(name string, held bool) {
+	leaseMu.Lock()
+	defer leaseMu.Unlock()
+	status := leases[name]
+	if status.Held != held {
+		status.Changes++
+	}
+	status.Held = held
+	status.Since = nil
+	if held {
+		now := time.Now()
+		status.Since = &now
+	}
+	leases[name] = status
+}
+
+func LeaseSnapshot() map[string]LeaseStatus {
+	leaseMu.Lock()
+	defer leaseMu.Unlock()
+	out := make(map[string]LeaseStatus, len(leases))
+	for name, status := range leases {
+		out[name] = status
+	}
+	return out
+}
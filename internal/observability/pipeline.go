@@ -0,0 +1,78 @@
+package observability
+
+import (
+	"sync"
+	"time"
+)
+
+// StageStats describes one ingestion pipeline stage since startup.
+type StageStats struct {
+	In         uint64  `json:"in"`
+	Out        uint64  `json:"out"`
+	Dropped    uint64  `json:"dropped"`
+	Queue      int     `json:"queue"`
+	AvgSeconds float64 `json:"avg_seconds"`
+}
+
+type stageCounters struct {
+	in, out, dropped uint64
+	queue            int
+	nanos            uint64
+}
+
+var (
+	stageMu sync.Mutex
+	stages  = map[string]*stageCounters{}
+)
+
+func stage(name string) *stageCounters {
+	c, ok := stages[name]
+	if !ok {
+		c = &stageCounters{}
+		stages[name] = c
+	}
+	return c
+}
+
+// ObserveStage records that a stage handled n items in d, with queue items
+// still waiting on its input.
+func ObserveStage(name string, n int, d time.Duration, queue int) {
+	stageMu.Lock()
+	c := stage(name)
+	c.in += uint64(n)
+	c.nanos += uint64(d)
+	c.queue = queue
+	stageMu.Unlock()
+}
+
+func AddStageOut(name string, n int) {
+	stageMu.Lock()
+	stage(name).out += uint64(n)
+	stageMu.Unlock()
+}
+
+func AddStageDropped(name string, n int) {
+	stageMu.Lock()
+	stage(name).dropped += uint64(n)
+	stageMu.Unlock()
+}
+
+func PipelineSnapshot() map[string]StageStats {
+	stageMu.Lock()
+	defer stageMu.Unlock()
+	out := make(map[string]StageStats, len(stages))
+	for name, c := range stages {
+		avg := 0.0
+		if c.in > 0 {
+			avg = float64(c.nanos) / float64(c.in) / 1e9
+		}
+		out[name] = StageStats{
+			In:         c.in,
+			Out:        c.out,
+			Dropped:    c.dropped,
+			Queue:      c.queue,
+			AvgSeconds: avg,
+		}
+	}
+	return out
+}
@@ -6,19 +6,28 @@ import (
 )
 
 type StatsSnapshot struct {
-	PagesCrawled      uint64            `json:"pages_crawled"`
-	JobsDiscovered    uint64            `json:"jobs_discovered"`
-	JobsExtracted     uint64            `json:"jobs_extracted"`
-	AICalls           uint64            `json:"ai_calls"`
-	ErrorsTotal       uint64            `json:"errors_total"`
-	CrawlSecondsAvg   float64           `json:"crawl_seconds_avg"`
-	URLsDiscovered    uint64            `json:"urls_discovered"`
-	SourcesPromoted   uint64            `json:"sources_promoted"`
-	ATSDetected       uint64            `json:"ats_detected"`
-	SourcesZeroJobs   uint64            `json:"sources_zero_jobs"`
-	SourceDecisions   map[string]uint64 `json:"source_decisions,omitempty"`
-	ErrorsByType      map[string]uint64 `json:"errors_by_type,omitempty"`
-	ErrorsByComponent map[string]uint64 `json:"errors_by_component,omitempty"`
+	PagesCrawled      uint64                 `json:"pages_crawled"`
+	JobsDiscovered    uint64                 `json:"jobs_discovered"`
+	JobsExtracted     uint64                 `json:"jobs_extracted"`
+	AICalls           uint64                 `json:"ai_calls"`
+	ErrorsTotal       uint64                 `json:"errors_total"`
+	CrawlSecondsAvg   float64                `json:"crawl_seconds_avg"`
+	URLsDiscovered    uint64                 `json:"urls_discovered"`
+	SourcesPromoted   uint64                 `json:"sources_promoted"`
+	ATSDetected       uint64                 `json:"ats_detected"`
+	SourcesZeroJobs   uint64                 `json:"sources_zero_jobs"`
+	JobsUnchanged     uint64                 `json:"jobs_unchanged"`
+	JobsRuleRejected  uint64                 `json:"jobs_rule_rejected"`
+	SourcesProcessed  uint64                 `json:"sources_processed"`
+	SourcesSkipped    uint64                 `json:"sources_skipped"`
+	SourcesCircuit    uint64                 `json:"sources_circuit_open"`
+	SourceDecisions   map[string]uint64      `json:"source_decisions,omitempty"`
+	Pipeline          map[string]StageStats  `json:"pipeline,omitempty"`
+	Leases            map[string]LeaseStatus `json:"leases,omitempty"`
+	Tasks             map[string]uint64      `json:"tasks,omitempty"`
+	AICache           map[string]uint64      `json:"ai_cache,omitempty"`
+	ErrorsByType      map[string]uint64      `json:"errors_by_type,omitempty"`
+	ErrorsByComponent map[string]uint64      `json:"errors_by_component,omitempty"`
 }
 
 var (
@@ -31,12 +40,19 @@ var (
 	sourcesPromoted uint64
 	atsDetected     uint64
 	sourcesZeroJobs uint64
+	jobsUnchanged   uint64
+	jobsRuleReject  uint64
+	sourcesDone     uint64
+	sourcesSkipped  uint64
+	sourcesCircuit  uint64
 
 	crawlCount uint64
 	crawlNanos uint64
 
 	statsMu           sync.Mutex
 	sourceDecisions   = map[string]uint64{}
+	taskOutcomes      = map[string]uint64{}
+	aiCache           = map[string]uint64{}
 	errorsByType      = map[string]uint64{}
 	errorsByComponent = map[string]uint64{}
 )
@@ -73,6 +89,27 @@ func IncSourcesZeroJobs(_ string) {
 	atomic.AddUint64(&sourcesZeroJobs, 1)
 }
 
+func IncJobsUnchanged(_ string) {
+	atomic.AddUint64(&jobsUnchanged, 1)
+}
+
+func IncJobsRuleRejected(_ string) {
+	atomic.AddUint64(&jobsRuleReject, 1)
+}
+
+func AddSourcesProcessed(n int) {
+	atomic.AddUint64(&sourcesDone, uint64(n))
+}
+
+func AddSourcesSkipped(n int) {
+	atomic.AddUint64(&sourcesSkipped, uint64(n))
+}
+
+// IncSourcesCircuitOpen counts scrapes skipped because a circuit was open.
+func IncSourcesCircuitOpen(_ string) {
+	atomic.AddUint64(&sourcesCircuit, 1)
+}
+
 func IncAICall(_ string) {
 	atomic.AddUint64(&aiCalls, 1)
 }
@@ -86,6 +123,22 @@ func IncSourceDecision(result string) {
 	statsMu.Unlock()
 }
 
+// IncTaskOutcome counts a finished task attempt as "<type>.<outcome>", where
+// outcome is done, retried or dead.
+func IncTaskOutcome(taskType, outcome string) {
+	statsMu.Lock()
+	taskOutcomes[taskType+"."+outcome]++
+	statsMu.Unlock()
+}
+
+// IncAICache counts an AI cache lookup as "<operation>.<result>", where
+// result is hit or miss.
+func IncAICache(operation, result string) {
+	statsMu.Lock()
+	aiCache[operation+"."+result]++
+	statsMu.Unlock()
+}
+
 func ObserveCrawlDuration(_ string, seconds float64) {
 	if seconds <= 0 {
 		return
@@ -111,6 +164,8 @@ func IncError(errType, component string) {
 func Snapshot() StatsSnapshot {
 	statsMu.Lock()
 	sourceCopy := copyMap(sourceDecisions)
+	tasksCopy := copyMap(taskOutcomes)
+	aiCacheCopy := copyMap(aiCache)
 	errorsTypeCopy := copyMap(errorsByType)
 	errorsComponentCopy := copyMap(errorsByComponent)
 	statsMu.Unlock()
@@ -132,7 +187,16 @@ func Snapshot() StatsSnapshot {
 		SourcesPromoted:   atomic.LoadUint64(&sourcesPromoted),
 		ATSDetected:       atomic.LoadUint64(&atsDetected),
 		SourcesZeroJobs:   atomic.LoadUint64(&sourcesZeroJobs),
+		JobsUnchanged:     atomic.LoadUint64(&jobsUnchanged),
+		JobsRuleRejected:  atomic.LoadUint64(&jobsRuleReject),
+		SourcesProcessed:  atomic.LoadUint64(&sourcesDone),
+		SourcesSkipped:    atomic.LoadUint64(&sourcesSkipped),
+		SourcesCircuit:    atomic.LoadUint64(&sourcesCircuit),
 		SourceDecisions:   sourceCopy,
+		Pipeline:          PipelineSnapshot(),
+		Leases:            LeaseSnapshot(),
+		Tasks:             tasksCopy,
+		AICache:           aiCacheCopy,
 		ErrorsByType:      errorsTypeCopy,
 		ErrorsByComponent: errorsComponentCopy,
 	}
@@ -0,0 +1,327 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type fieldType int
+
+const (
+	stringField fieldType = iota
+	numberField
+	boolField
+)
+
+// fields lists what expressions can reference and how to read it from a Job.
+var fields = map[string]struct {
+	typ   fieldType
+	value func(Job) any
+}{
+	"title":            {stringField, func(j Job) any { return j.Title }},
+	"company":          {stringField, func(j Job) any { return j.Company }},
+	"location":         {stringField, func(j Job) any { return j.Location }},
+	"description":      {stringField, func(j Job) any { return j.Description }},
+	"source_type":      {stringField, func(j Job) any { return j.SourceType }},
+	"url":              {stringField, func(j Job) any { return j.URL }},
+	"salary":           {numberField, func(j Job) any { return float64(j.Salary) }},
+	"remote":           {boolField, func(j Job) any { return j.Remote }},
+	"seniority":        {stringField, func(j Job) any { return j.Seniority }},
+	"role_family":      {stringField, func(j Job) any { return j.RoleFamily }},
+	"experience_years": {numberField, func(j Job) any { return float64(j.ExperienceYears) }},
+	"age_days":         {numberField, func(j Job) any { return float64(j.AgeDays) }},
+	"profile":          {stringField, func(j Job) any { return j.Profile }},
+	"location_allowed": {boolField, func(j Job) any { return j.LocationAllowed }},
+	"profile_fit":      {boolField, func(j Job) any { return j.ProfileFit }},
+	"excluded_keyword": {stringField, func(j Job) any { return j.ExcludedKeyword }},
+	"score":            {numberField, func(j Job) any { return float64(j.Score) }},
+}
+
+// scoreField is only known once a job has been scored; rules that read it
+// run after scoring instead of before.
+const scoreField = "score"
+
+type node interface {
+	eval(Job) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(j Job) bool { return n.left.eval(j) && n.right.eval(j) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(j Job) bool { return n.left.eval(j) || n.right.eval(j) }
+
+type notNode struct{ inner node }
+
+func (n notNode) eval(j Job) bool { return !n.inner.eval(j) }
+
+type predicate func(Job) bool
+
+func (p predicate) eval(j Job) bool { return p(j) }
+
+// Expr is a compiled boolean expression over job fields.
+type Expr struct {
+	source string
+	root   node
+	fields []string
+}
+
+// Match reports whether the job satisfies the expression.
+func (e *Expr) Match(j Job) bool {
+	return e.root.eval(j)
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Uses reports whether the expression references the named field.
+func (e *Expr) Uses(field string) bool {
+	return slices.Contains(e.fields, field)
+}
+
+// Compile parses an expression such as
+//
+//	title ~ "golang|go developer" and not (company in ["Acme", "Initech"]) and salary >= 100000
+//
+// String comparisons are case-insensitive; "~"/"matches" take a regular expression.
+func Compile(expression string) (*Expr, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+	return &Expr{source: expression, root: root, fields: p.fields}, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokIdent && tok.text == word
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isKeyword("not") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) but got %s", tok)
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return nil, fmt.Errorf("expected field name but got %s", tok)
+	}
+	field, ok := fields[tok.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", tok.text)
+	}
+	get := field.value
+	if !slices.Contains(p.fields, tok.text) {
+		p.fields = append(p.fields, tok.text)
+	}
+
+	op := p.peek()
+	isOp := op.kind == tokOp || (op.kind == tokIdent && slices.Contains([]string{"contains", "matches", "in"}, op.text))
+	if !isOp {
+		// A bare boolean field reads as "field == true".
+		if field.typ != boolField {
+			return nil, fmt.Errorf("field %q needs an operator", tok.text)
+		}
+		return predicate(func(j Job) bool { return get(j).(bool) }), nil
+	}
+	p.next()
+
+	switch field.typ {
+	case stringField:
+		return p.stringComparison(tok.text, op.text, get)
+	case numberField:
+		return p.numberComparison(tok.text, op.text, get)
+	default:
+		return p.boolComparison(tok.text, op.text, get)
+	}
+}
+
+func (p *parser) stringComparison(name, op string, get func(Job) any) (node, error) {
+	str := func(j Job) string { return strings.ToLower(get(j).(string)) }
+
+	if op == "in" {
+		list, err := p.parseStringList()
+		if err != nil {
+			return nil, err
+		}
+		return predicate(func(j Job) bool { return slices.Contains(list, str(j)) }), nil
+	}
+
+	tok := p.next()
+	if tok.kind != tokString {
+		return nil, fmt.Errorf("field %q compares to a string but got %s", name, tok)
+	}
+	want := strings.ToLower(tok.text)
+
+	switch op {
+	case "==":
+		return predicate(func(j Job) bool { return str(j) == want }), nil
+	case "!=":
+		return predicate(func(j Job) bool { return str(j) != want }), nil
+	case "contains":
+		return predicate(func(j Job) bool { return strings.Contains(str(j), want) }), nil
+	case "~", "matches":
+		re, err := regexp.Compile("(?i)" + tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for %q: %w", name, err)
+		}
+		return predicate(func(j Job) bool { return re.MatchString(get(j).(string)) }), nil
+	default:
+		return nil, fmt.Errorf("operator %q is not valid for text field %q", op, name)
+	}
+}
+
+func (p *parser) numberComparison(name, op string, get func(Job) any) (node, error) {
+	tok := p.next()
+	if tok.kind != tokNumber {
+		return nil, fmt.Errorf("field %q compares to a number but got %s", name, tok)
+	}
+	want, err := strconv.ParseFloat(strings.ReplaceAll(tok.text, "_", ""), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s", tok)
+	}
+	num := func(j Job) float64 { return get(j).(float64) }
+
+	switch op {
+	case "==":
+		return predicate(func(j Job) bool { return num(j) == want }), nil
+	case "!=":
+		return predicate(func(j Job) bool { return num(j) != want }), nil
+	case "<":
+		return predicate(func(j Job) bool { return num(j) < want }), nil
+	case "<=":
+		return predicate(func(j Job) bool { return num(j) <= want }), nil
+	case ">":
+		return predicate(func(j Job) bool { return num(j) > want }), nil
+	case ">=":
+		return predicate(func(j Job) bool { return num(j) >= want }), nil
+	default:
+		return nil, fmt.Errorf("operator %q is not valid for numeric field %q", op, name)
+	}
+}
+
+func (p *parser) boolComparison(name, op string, get func(Job) any) (node, error) {
+	tok := p.next()
+	if tok.kind != tokIdent || (tok.text != "true" && tok.text != "false") {
+		return nil, fmt.Errorf("field %q compares to true or false but got %s", name, tok)
+	}
+	want := tok.text == "true"
+
+	switch op {
+	case "==":
+		return predicate(func(j Job) bool { return get(j).(bool) == want }), nil
+	case "!=":
+		return predicate(func(j Job) bool { return get(j).(bool) != want }), nil
+	default:
+		return nil, fmt.Errorf("operator %q is not valid for boolean field %q", op, name)
+	}
+}
+
+func (p *parser) parseStringList() ([]string, error) {
+	if tok := p.next(); tok.kind != tokLBracket {
+		return nil, fmt.Errorf("expected [ but got %s", tok)
+	}
+	var list []string
+	for {
+		tok := p.next()
+		if tok.kind == tokRBracket && len(list) == 0 {
+			return list, nil
+		}
+		if tok.kind != tokString {
+			return nil, fmt.Errorf("expected string in list but got %s", tok)
+		}
+		list = append(list, strings.ToLower(tok.text))
+
+		tok = p.next()
+		switch tok.kind {
+		case tokComma:
+		case tokRBracket:
+			return list, nil
+		default:
+			return nil, fmt.Errorf("expected , or ] but got %s", tok)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package rules implements the filter rule language used by ingestion to
+// accept, reject or boost scraped jobs. Rules are evaluated once per job and
+// profile before scoring; rules that read score run again after it.
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+const (
+	ActionAccept = "accept"
+	ActionReject = "reject"
+	ActionBoost  = "boost"
+)
+
+// Job is the view of a scraped posting that expressions are evaluated against.
+type Job struct {
+	Title       string
+	Company     string
+	Location    string
+	Description string
+	SourceType  string
+	URL         string
+	Salary      int
+	Remote      bool
+
+	Seniority       string
+	RoleFamily      string
+	ExperienceYears int
+
+	// AgeDays is how old the posting was when it was first seen; 0 when the
+	// board gives no date.
+	AgeDays int
+
+	// Profile fields describe how the job relates to the profile it is
+	// being evaluated for.
+	Profile         string
+	LocationAllowed bool
+	ProfileFit      bool
+	ExcludedKeyword string
+
+	// Score is the final match score. It is zero before scoring.
+	Score int
+}
+
+// Definition is a stored rule before compilation.
+type Definition struct {
+	ID         int
+	Name       string
+	Expression string
+	Action     string
+	Boost      int
+}
+
+type rule struct {
+	Definition
+	expr *Expr
+}
+
+// Decision records the outcome of evaluating a rule set against one job.
+// RuleID and RuleName identify the accept/reject rule that decided the job;
+// they are empty when only boosts (or nothing) matched.
+type Decision struct {
+	Action   string   `json:"action,omitempty"`
+	RuleID   int      `json:"rule_id,omitempty"`
+	RuleName string   `json:"rule_name,omitempty"`
+	Boost    int      `json:"boost,omitempty"`
+	Boosts   []string `json:"boosts,omitempty"`
+}
+
+func (d Decision) Accepted() bool { return d.Action == ActionAccept }
+
+func (d Decision) Rejected() bool { return d.Action == ActionReject }
+
+// Set is an ordered, compiled list of rules.
+type Set struct {
+	rules   []rule
+	version string
+}
+
+// Validate checks that a definition has a known action and a valid expression.
+func Validate(def Definition) error {
+	switch def.Action {
+	case ActionAccept, ActionReject, ActionBoost:
+	default:
+		return fmt.Errorf("unknown action %q", def.Action)
+	}
+	expr, err := Compile(def.Expression)
+	if err != nil {
+		return fmt.Errorf("rule %q: %w", ruleLabel(def), err)
+	}
+	if def.Action == ActionBoost && expr.Uses(scoreField) {
+		return fmt.Errorf("rule %q: boost rules cannot use %s", ruleLabel(def), scoreField)
+	}
+	return nil
+}
+
+// NewSet compiles definitions in order. Invalid rules are left out of the set
+// and reported so one bad rule does not disable filtering altogether.
+func NewSet(defs []Definition) (*Set, []error) {
+	set := &Set{}
+	var errs []error
+	h := sha256.New()
+	for _, def := range defs {
+		if err := Validate(def); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		expr, _ := Compile(def.Expression)
+		set.rules = append(set.rules, rule{Definition: def, expr: expr})
+		fmt.Fprintf(h, "%d\x1f%s\x1f%s\x1f%d\x00", def.ID, def.Expression, def.Action, def.Boost)
+	}
+	set.version = hex.EncodeToString(h.Sum(nil))[:16]
+	return set, errs
+}
+
+// Version fingerprints the compiled rules; it changes whenever a rule does.
+func (s *Set) Version() string {
+	if s == nil {
+		return ""
+	}
+	return s.version
+}
+
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// Evaluate runs the rules that do not read score, in order. Boost rules
+// accumulate and evaluation continues; the first matching accept or reject
+// rule ends evaluation.
+func (s *Set) Evaluate(job Job) Decision {
+	var d Decision
+	if s == nil {
+		return d
+	}
+	for _, r := range s.rules {
+		if r.expr.Uses(scoreField) || !r.expr.Match(job) {
+			continue
+		}
+		switch r.Action {
+		case ActionBoost:
+			d.Boost += r.Boost
+			d.Boosts = append(d.Boosts, ruleLabel(r.Definition)+":"+strconv.Itoa(r.Boost))
+		case ActionAccept, ActionReject:
+			d.decide(r.Definition)
+			return d
+		}
+	}
+	if d.Boost != 0 {
+		d.Action = ActionBoost
+	}
+	return d
+}
+
+// EvaluateScore finishes a decision from Evaluate once job.Score is known by
+// running the rules that read score. A job already accepted or rejected keeps
+// its decision.
+func (s *Set) EvaluateScore(job Job, d Decision) Decision {
+	if s == nil || d.Accepted() || d.Rejected() {
+		return d
+	}
+	for _, r := range s.rules {
+		if r.expr.Uses(scoreField) && r.expr.Match(job) {
+			d.decide(r.Definition)
+			return d
+		}
+	}
+	return d
+}
+
+func (d *Decision) decide(def Definition) {
+	d.Action = def.Action
+	d.RuleID = def.ID
+	d.RuleName = ruleLabel(def)
+}
+
+func ruleLabel(def Definition) string {
+	if def.Name != "" {
+		return def.Name
+	}
+	return "rule-" + strconv.Itoa(def.ID)
+}
@@ -11,6 +11,13 @@ type RawJob struct {
 	Company     string
 	Location    string
 	PostedAt    time.Time
+	// Structured details some boards publish, spelled as the board does.
+	// Zero values mean the board did not say.
+	EmploymentType string
+	SalaryMin      int
+	SalaryMax      int
+	SalaryCurrency string
+	SalaryPeriod   string
 }
 
 type JobScraper interface {
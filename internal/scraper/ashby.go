@@ -140,12 +140,13 @@ func (a *AshbyScraper) FetchJobs(since time.Time) ([]RawJob, error) {
 		description := strings.Join(descParts, " - ")
 
 		jobs = append(jobs, RawJob{
-			URL:         baseURL + "/" + jobID,
-			Title:       posting.Title,
-			Description: description,
-			Company:     company,
-			Location:    loc,
-			PostedAt:    posted,
+			URL:            baseURL + "/" + jobID,
+			Title:          posting.Title,
+			Description:    description,
+			Company:        company,
+			Location:       loc,
+			PostedAt:       posted,
+			EmploymentType: posting.EmploymentType,
 		})
 	}
 
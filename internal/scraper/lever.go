@@ -10,17 +10,27 @@ import (
 )
 
 type leverPosting struct {
-	ID          string   `json:"id"`
-	Text        string   `json:"text"`
-	HostedURL   string   `json:"hostedUrl"`
-	Categories  category `json:"categories"`
-	CreatedAt   int64    `json:"createdAt"`
-	Description string   `json:"descriptionPlain"`
+	ID          string       `json:"id"`
+	Text        string       `json:"text"`
+	HostedURL   string       `json:"hostedUrl"`
+	Categories  category     `json:"categories"`
+	CreatedAt   int64        `json:"createdAt"`
+	Description string       `json:"descriptionPlain"`
+	SalaryRange *leverSalary `json:"salaryRange"`
 }
 
 type category struct {
-	Team     string `json:"team"`
-	Location string `json:"location"`
+	Team       string `json:"team"`
+	Location   string `json:"location"`
+	Commitment string `json:"commitment"`
+}
+
+type leverSalary struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Currency string  `json:"currency"`
+	// Interval is e.g. "per-year-salary" or "per-hour-wage".
+	Interval string `json:"interval"`
 }
 
 type LeverScraper struct {
@@ -71,14 +81,20 @@ func (l *LeverScraper) FetchJobs(since time.Time) ([]RawJob, error) {
 		if posted.Before(since) {
 			continue
 		}
-		jobs = append(jobs, RawJob{
-			URL:         p.HostedURL,
-			Title:       p.Text,
-			Description: p.Description,
-			Company:     companyFromLeverURL(p.HostedURL),
-			Location:    p.Categories.Location,
-			PostedAt:    posted,
-		})
+		job := RawJob{
+			URL:            p.HostedURL,
+			Title:          p.Text,
+			Description:    p.Description,
+			Company:        companyFromLeverURL(p.HostedURL),
+			Location:       p.Categories.Location,
+			PostedAt:       posted,
+			EmploymentType: p.Categories.Commitment,
+		}
+		if s := p.SalaryRange; s != nil && s.Max > 0 {
+			job.SalaryMin, job.SalaryMax = int(s.Min), int(s.Max)
+			job.SalaryCurrency, job.SalaryPeriod = s.Currency, s.Interval
+		}
+		jobs = append(jobs, job)
 	}
 	return jobs, nil
 }
@@ -18,6 +18,8 @@ type remoteOKJob struct {
 	Date        string   `json:"date"`
 	Description string   `json:"description"`
 	Location    string   `json:"location"`
+	SalaryMin   int      `json:"salary_min"`
+	SalaryMax   int      `json:"salary_max"`
 }
 
 type RemoteOKScraper struct {
@@ -60,14 +62,20 @@ func (r *RemoteOKScraper) FetchJobs(since time.Time) ([]RawJob, error) {
 		if !postedAt.IsZero() && postedAt.Before(since) {
 			continue
 		}
-		jobs = append(jobs, RawJob{
+		job := RawJob{
 			URL:         j.URL,
 			Title:       j.Position,
 			Description: j.Description,
 			Company:     j.Company,
 			Location:    j.Location,
 			PostedAt:    postedAt,
-		})
+		}
+		// RemoteOK salaries are yearly USD.
+		if j.SalaryMax > 0 {
+			job.SalaryMin, job.SalaryMax = j.SalaryMin, j.SalaryMax
+			job.SalaryCurrency, job.SalaryPeriod = "USD", "year"
+		}
+		jobs = append(jobs, job)
 	}
 	return jobs, nil
 }
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GetAIResponse returns a cached AI answer that has not expired.
+func (s *Store) GetAIResponse(ctx context.Context, operation, model, promptVersion, inputHash string) ([]byte, bool, error) {
+	var response []byte
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT
+			response
+		FROM
+			ai_cache
+		WHERE
+			operation = $1
+			AND model = $2
+			AND prompt_version = $3
+			AND input_hash = $4
+			AND expires_at > NOW()`,
+		operation,
+		model,
+		promptVersion,
+		inputHash,
+	).Scan(&response)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return response, true, nil
+}
+
+// PutAIResponse caches an AI answer for ttl, replacing an earlier one.
+func (s *Store) PutAIResponse(ctx context.Context, operation, model, promptVersion, inputHash string, response []byte, ttl time.Duration) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO
+			ai_cache (
+				operation,
+				model,
+				prompt_version,
+				input_hash,
+				response,
+				created_at,
+				expires_at
+			)
+		VALUES
+			(
+				$1,
+				$2,
+				$3,
+				$4,
+				$5,
+				NOW(),
+				NOW() + make_interval(secs => $6)
+			)
+		ON CONFLICT (operation, model, prompt_version, input_hash) DO
+		UPDATE
+		SET
+			response = EXCLUDED.response,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at`,
+		operation,
+		model,
+		promptVersion,
+		inputHash,
+		response,
+		ttl.Seconds(),
+	)
+	return err
+}
+
+// DeleteExpiredAIResponses removes expired cache entries and returns how many.
+func (s *Store) DeleteExpiredAIResponses(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM
+			ai_cache
+		WHERE
+			expires_at <= NOW()`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
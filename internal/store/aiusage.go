@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// AIUsage is one day of AI requests to one model for one operation. Days are
+// UTC dates.
+type AIUsage struct {
+	Day          time.Time `json:"day"`
+	Model        string    `json:"model"`
+	Operation    string    `json:"operation"`
+	Requests     int       `json:"requests"`
+	Failures     int       `json:"failures"`
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	CostUSD      float64   `json:"cost_usd"`
+}
+
+// AIUsageTotals sums AI usage over a period.
+type AIUsageTotals struct {
+	Requests     int     `json:"requests"`
+	Failures     int     `json:"failures"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// RecordAIUsage adds one request to today's usage of model for operation.
+func (s *Store) RecordAIUsage(ctx context.Context, model, operation string, failed bool, inputTokens, outputTokens int, costUSD float64) error {
+	failures := 0
+	if failed {
+		failures = 1
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO
+			ai_usage (
+				day,
+				model,
+				operation,
+				requests,
+				failures,
+				input_tokens,
+				output_tokens,
+				cost_usd
+			)
+		VALUES
+			(
+				(NOW() AT TIME ZONE 'UTC')::DATE,
+				$1,
+				$2,
+				1,
+				$3,
+				$4,
+				$5,
+				$6
+			)
+		ON CONFLICT (day, model, operation) DO
+		UPDATE
+		SET
+			requests = ai_usage.requests + 1,
+			failures = ai_usage.failures + EXCLUDED.failures,
+			input_tokens = ai_usage.input_tokens + EXCLUDED.input_tokens,
+			output_tokens = ai_usage.output_tokens + EXCLUDED.output_tokens,
+			cost_usd = ai_usage.cost_usd + EXCLUDED.cost_usd`,
+		model,
+		operation,
+		failures,
+		inputTokens,
+		outputTokens,
+		costUSD,
+	)
+	return err
+}
+
+// GetAIUsageTotals sums the usage of every model from the UTC date of since.
+func (s *Store) GetAIUsageTotals(ctx context.Context, since time.Time) (AIUsageTotals, error) {
+	var t AIUsageTotals
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(SUM(requests), 0),
+			COALESCE(SUM(failures), 0),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(cost_usd), 0)
+		FROM
+			ai_usage
+		WHERE
+			day >= $1::DATE`,
+		since.UTC().Format(time.DateOnly),
+	).Scan(&t.Requests, &t.Failures, &t.InputTokens, &t.OutputTokens, &t.CostUSD)
+	return t, err
+}
+
+// ListAIUsage returns the usage rows from the UTC date of since, newest day
+// first.
+func (s *Store) ListAIUsage(ctx context.Context, since time.Time) ([]AIUsage, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT
+			day,
+			model,
+			operation,
+			requests,
+			failures,
+			input_tokens,
+			output_tokens,
+			cost_usd
+		FROM
+			ai_usage
+		WHERE
+			day >= $1::DATE
+		ORDER BY
+			day DESC,
+			model,
+			operation`,
+		since.UTC().Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []AIUsage
+	for rows.Next() {
+		var u AIUsage
+		if err := rows.Scan(&u.Day, &u.Model, &u.Operation, &u.Requests, &u.Failures, &u.InputTokens, &u.OutputTokens, &u.CostUSD); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,315 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/baxromumarov/job-hunter/internal/urlutil"
+	"github.com/lib/pq"
+)
+
+const (
+	CircuitScopeSource = "source"
+	CircuitScopeHost   = "host"
+
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// Circuit is the breaker state of one source or host. Failures counts
+// consecutive failed scrapes; Trips counts how often the circuit opened in a
+// row and drives the cooldown growth.
+type Circuit struct {
+	State         string     `json:"state"`
+	Failures      int        `json:"failures"`
+	Trips         int        `json:"trips,omitempty"`
+	OpenedAt      *time.Time `json:"opened_at,omitempty"`
+	RetryAt       *time.Time `json:"retry_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+}
+
+// CircuitPolicy sets when a circuit opens and for how long. The cooldown
+// doubles with every consecutive trip, up to MaxCooldown.
+type CircuitPolicy struct {
+	Threshold   int
+	Cooldown    time.Duration
+	MaxCooldown time.Duration
+}
+
+// SourceCircuitKey is the circuit key of a source.
+func SourceCircuitKey(sourceID int) string {
+	return strconv.Itoa(sourceID)
+}
+
+// HostCircuitKey is the circuit key of a host, or "" when the host has no
+// circuit. Shared ATS hosts serve one board per company, so a failing board
+// must not trip the others; their boards only have source circuits.
+func HostCircuitKey(host string) string {
+	if urlutil.IsATSHost(host) {
+		return ""
+	}
+	return host
+}
+
+const circuitColumns = `
+	scope,
+	key,
+	state,
+	failures,
+	trips,
+	opened_at,
+	retry_at,
+	COALESCE(last_error, ''),
+	last_failure_at`
+
+func scanCircuit(row rowScanner) (scope, key string, c *Circuit, err error) {
+	var (
+		circuit       Circuit
+		openedAt      sql.NullTime
+		retryAt       sql.NullTime
+		lastFailureAt sql.NullTime
+	)
+	if err := row.Scan(
+		&scope,
+		&key,
+		&circuit.State,
+		&circuit.Failures,
+		&circuit.Trips,
+		&openedAt,
+		&retryAt,
+		&circuit.LastError,
+		&lastFailureAt,
+	); err != nil {
+		return "", "", nil, err
+	}
+	circuit.OpenedAt = scanNullTime(openedAt)
+	circuit.RetryAt = scanNullTime(retryAt)
+	circuit.LastFailureAt = scanNullTime(lastFailureAt)
+	return scope, key, &circuit, nil
+}
+
+// halfOpenProbeTimeout lets a new probe through a half-open circuit whose
+// previous probe never reported back (e.g. the process died).
+const halfOpenProbeTimeout = 30 * time.Minute
+
+// AcquireCircuit decides whether a scrape may go through the circuit. Closed
+// circuits (and unknown keys) allow it. An open circuit whose cooldown ended
+// moves to half-open and lets exactly this caller probe, reported by probe;
+// every other caller is refused until the probe reports back. When refused,
+// retryAt says when the circuit is worth asking again.
+func (s *Store) AcquireCircuit(ctx context.Context, scope, key string) (allowed, probe bool, retryAt time.Time, err error) {
+	var (
+		state string
+		retry sql.NullTime
+	)
+	err = s.db.QueryRowContext(
+		ctx,
+		`WITH prev AS (
+			SELECT
+				state,
+				retry_at,
+				updated_at
+			FROM
+				circuits
+			WHERE
+				scope = $1
+				AND key = $2
+			FOR UPDATE
+		),
+		probe AS (
+			SELECT
+				(prev.state = 'open' AND prev.retry_at <= NOW())
+				OR (prev.state = 'half_open' AND prev.updated_at < NOW() - make_interval(secs => $3)) AS granted
+			FROM
+				prev
+		)
+		UPDATE
+			circuits c
+		SET
+			state = CASE WHEN probe.granted THEN 'half_open' ELSE c.state END,
+			updated_at = CASE WHEN probe.granted THEN NOW() ELSE c.updated_at END
+		FROM
+			probe
+		WHERE
+			c.scope = $1
+			AND c.key = $2
+		RETURNING
+			c.state,
+			c.retry_at,
+			probe.granted`,
+		scope,
+		key,
+		halfOpenProbeTimeout.Seconds(),
+	).Scan(&state, &retry, &allowed)
+	if err == sql.ErrNoRows {
+		return true, false, time.Time{}, nil
+	}
+	if err != nil {
+		return false, false, time.Time{}, err
+	}
+	if state == CircuitClosed {
+		return true, false, time.Time{}, nil
+	}
+	if retry.Valid {
+		retryAt = retry.Time
+	}
+	if !allowed && state == CircuitHalfOpen {
+		// Another probe is in flight; ask again once it should have finished.
+		retryAt = time.Now().Add(halfOpenProbeTimeout)
+	}
+	return allowed, allowed, retryAt, nil
+}
+
+// ReleaseCircuitProbe hands back a probe granted by AcquireCircuit that was
+// never used. The circuit returns to open with its cooldown already over, so
+// the next caller gets the probe.
+func (s *Store) ReleaseCircuitProbe(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			circuits
+		SET
+			state = 'open',
+			updated_at = NOW()
+		WHERE
+			scope = $1
+			AND key = $2
+			AND state = 'half_open'`,
+		scope,
+		key,
+	)
+	return err
+}
+
+// RecordCircuitSuccess closes the circuit and resets its counters. The last
+// error is kept for reference.
+func (s *Store) RecordCircuitSuccess(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			circuits
+		SET
+			state = 'closed',
+			failures = 0,
+			trips = 0,
+			opened_at = NULL,
+			retry_at = NULL,
+			updated_at = NOW()
+		WHERE
+			scope = $1
+			AND key = $2
+			AND (state <> 'closed' OR failures > 0)`,
+		scope,
+		key,
+	)
+	return err
+}
+
+// RecordCircuitFailure counts a failed scrape. The circuit opens once the
+// consecutive failures reach the policy threshold, or straight away when a
+// half-open probe failed.
+func (s *Store) RecordCircuitFailure(ctx context.Context, scope, key, message string, policy CircuitPolicy) (*Circuit, error) {
+	_, _, circuit, err := scanCircuit(s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			circuits (scope, key, state, failures, last_error, last_failure_at, updated_at)
+		VALUES
+			($1, $2, 'closed', 1, $3, NOW(), NOW())
+		ON CONFLICT (scope, key) DO
+		UPDATE
+		SET
+			failures = circuits.failures + 1,
+			last_error = EXCLUDED.last_error,
+			last_failure_at = NOW(),
+			updated_at = NOW()
+		RETURNING `+circuitColumns,
+		scope,
+		key,
+		message,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if circuit.State != CircuitHalfOpen && (circuit.State == CircuitOpen || circuit.Failures < policy.Threshold) {
+		return circuit, nil
+	}
+
+	_, _, circuit, err = scanCircuit(s.db.QueryRowContext(
+		ctx,
+		`UPDATE
+			circuits
+		SET
+			state = 'open',
+			trips = trips + 1,
+			opened_at = NOW(),
+			retry_at = NOW() + make_interval(secs => LEAST($4::float8, $3::float8 * power(2, LEAST(trips, 16)))),
+			updated_at = NOW()
+		WHERE
+			scope = $1
+			AND key = $2
+		RETURNING `+circuitColumns,
+		scope,
+		key,
+		policy.Cooldown.Seconds(),
+		policy.MaxCooldown.Seconds(),
+	))
+	return circuit, err
+}
+
+// attachCircuits fills in the source and host circuits of sources that have one.
+func (s *Store) attachCircuits(ctx context.Context, sources []Source) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(sources))
+	hosts := make([]string, 0, len(sources))
+	for _, src := range sources {
+		keys = append(keys, SourceCircuitKey(src.ID))
+		if host := HostCircuitKey(src.Host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+circuitColumns+`
+		FROM
+			circuits
+		WHERE
+			(scope = 'source' AND key = ANY($1))
+			OR (scope = 'host' AND key = ANY($2))`,
+		pq.Array(keys),
+		pq.Array(hosts),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	bySource := make(map[string]*Circuit)
+	byHost := make(map[string]*Circuit)
+	for rows.Next() {
+		scope, key, circuit, err := scanCircuit(rows)
+		if err != nil {
+			return err
+		}
+		if scope == CircuitScopeSource {
+			bySource[key] = circuit
+		} else {
+			byHost[key] = circuit
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range sources {
+		sources[i].Circuit = bySource[SourceCircuitKey(sources[i].ID)]
+		sources[i].HostCircuit = byHost[sources[i].Host]
+	}
+	return nil
+}
@@ -3,13 +3,14 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"github.com/baxromumarov/job-hunter/internal/observability"
 	"github.com/baxromumarov/job-hunter/internal/urlutil"
@@ -60,6 +61,14 @@ func clampLimit(limit int, defaultLimit, maxLimit int) int {
 	return min(limit, maxLimit)
 }
 
+// nonNilStrings keeps empty filters as empty arrays; pq encodes a nil slice as NULL.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
 func normalizePagination(limit, offset int) (int, int) {
 	limit = clampLimit(limit, 20, 200)
 	if offset < 0 {
@@ -94,33 +103,83 @@ type Source struct {
 	LastScrapedAt  *time.Time `json:"last_scraped_at,omitempty"`
 	DiscoveredAt   *time.Time `json:"discovered_at,omitempty"`
 	Classification string     `json:"classification_reason,omitempty"`
-	ATSBacked      bool       `json:"ats_backed,omitempty"`
-	RecheckCount   int        `json:"recheck_count,omitempty"`
-	LastErrorType  string     `json:"last_error_type,omitempty"`
-	LastErrorMsg   string     `json:"last_error_message,omitempty"`
-	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	// ClassificationModel names the AI model behind the classification, or
+	// is empty when the heuristics decided.
+	ClassificationModel string     `json:"classification_model,omitempty"`
+	ATSBacked           bool       `json:"ats_backed,omitempty"`
+	RecheckCount        int        `json:"recheck_count,omitempty"`
+	LastErrorType       string     `json:"last_error_type,omitempty"`
+	LastErrorMsg        string     `json:"last_error_message,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+
+	// Adaptive scrape scheduling, see UpdateSourceSchedule.
+	NextScrapeAt            *time.Time `json:"next_scrape_at,omitempty"`
+	ScrapeIntervalMinutes   int        `json:"scrape_interval_minutes,omitempty"`
+	IntervalOverrideMinutes int        `json:"interval_override_minutes,omitempty"`
+	ErrorStreak             int        `json:"error_streak,omitempty"`
+	EmptyStreak             int        `json:"empty_streak,omitempty"`
+	LastNewJobs             int        `json:"last_new_jobs"`
+
+	// Circuit breaker state, when the source or its host ever failed.
+	Circuit     *Circuit `json:"circuit,omitempty"`
+	HostCircuit *Circuit `json:"host_circuit,omitempty"`
 }
 
 type Job struct {
-	ID           int        `json:"id"`
-	SourceID     int        `json:"source_id"`
-	SourceURL    string     `json:"source_url"`
-	SourceType   string     `json:"source_type"`
-	URL          string     `json:"url"`
-	Title        string     `json:"title"`
-	Description  string     `json:"description"`
-	Company      string     `json:"company"`
-	Location     string     `json:"location"`
-	MatchScore   int        `json:"match_score"`
-	MatchSummary string     `json:"match_summary"`
-	Applied      bool       `json:"applied"`
-	AppliedAt    *time.Time `json:"applied_at,omitempty"`
-	Rejected     bool       `json:"rejected"`
-	RejectedAt   *time.Time `json:"rejected_at,omitempty"`
-	Closed       bool       `json:"closed"`
-	ClosedAt     *time.Time `json:"closed_at,omitempty"`
-	PostedAt     *time.Time `json:"posted_at,omitempty"`
-	CreatedAt    time.Time  `json:"created_at"`
+	ID              int               `json:"id"`
+	SourceID        int               `json:"source_id"`
+	SourceURL       string            `json:"source_url"`
+	SourceType      string            `json:"source_type"`
+	URL             string            `json:"url"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	Company         string            `json:"company"`
+	Location        string            `json:"location"`
+	MatchScore      int               `json:"match_score"`
+	MatchSummary    string            `json:"match_summary"`
+	Applied         bool              `json:"applied"`
+	AppliedAt       *time.Time        `json:"applied_at,omitempty"`
+	Rejected        bool              `json:"rejected"`
+	RejectedAt      *time.Time        `json:"rejected_at,omitempty"`
+	Closed          bool              `json:"closed"`
+	ClosedAt        *time.Time        `json:"closed_at,omitempty"`
+	PostedAt        *time.Time        `json:"posted_at,omitempty"`
+	ContentHash     string            `json:"content_hash,omitempty"`
+	RuleDecision    *RuleDecision     `json:"rule_decision,omitempty"`
+	Keywords        *KeywordBreakdown `json:"keyword_breakdown,omitempty"`
+	Seniority       string            `json:"seniority,omitempty"`
+	ExperienceYears int               `json:"experience_years,omitempty"`
+	RoleFamily      string            `json:"role_family,omitempty"`
+	Facts           *JobFacts         `json:"facts,omitempty"`
+	// ProfileVersion, ScoredModel and ScoredAt record what produced MatchScore.
+	ProfileVersion int        `json:"profile_version"`
+	ScoredModel    string     `json:"scored_model,omitempty"`
+	ScoredAt       *time.Time `json:"scored_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// JobFingerprint is the stored content hash of a job, when it was first
+// stored, and what each profile that tracks it holds. Ingestion uses it to
+// reuse scores of unchanged postings and rescoring to find stale ones.
+type JobFingerprint struct {
+	ContentHash string
+	FirstSeen   time.Time
+	Profiles    map[int]ProfileFingerprint
+}
+
+// ProfileFingerprint is what one profile holds for a job: the profile
+// version and AI model the score was computed with, the score breakdown and
+// the rule decision. Breakdown is nil when a rule rejected the job before it
+// was ever scored. Triaged means the profile applied to, rejected or closed
+// the job, which keeps it listed.
+type ProfileFingerprint struct {
+	Version   int
+	Model     string
+	Keywords  KeywordBreakdown
+	Breakdown *ScoreBreakdown
+	Decision  *RuleDecision
+	Matched   bool
+	Triaged   bool
 }
 
 type StatPoint struct {
@@ -128,29 +187,50 @@ type StatPoint struct {
 	Value     float64   `json:"value"`
 }
 
-var ErrUnknownMetric = errors.New("unknown metric")
+var (
+	ErrUnknownMetric = errors.New("unknown metric")
+	ErrNotFound      = errors.New("not found")
+)
+
+// JobFilter narrows GetJobs by classification. Empty fields do not filter.
+type JobFilter struct {
+	Seniority          []string
+	RoleFamilies       []string
+	MaxExperienceYears int
+}
+
+// jobFilterSQL matches JobFilter against jobs aliased as j, with the filter
+// values bound to $2, $3 and $4.
+const jobFilterSQL = `
+			AND (cardinality($2::text[]) = 0 OR j.seniority = ANY($2))
+			AND (cardinality($3::text[]) = 0 OR j.role_family = ANY($3))
+			AND ($4 = 0 OR COALESCE(j.experience_years, 0) <= $4)`
 
-func (s *Store) GetJobs(ctx context.Context, limit, offset int) ([]Job, int, int, error) {
+// GetJobs lists the jobs matched for a profile, ranked by that profile's scores.
+func (s *Store) GetJobs(ctx context.Context, profileID int, filter JobFilter, limit, offset int) ([]Job, int, int, error) {
 	limit, offset = normalizePagination(limit, offset)
+	seniority := pq.Array(nonNilStrings(filter.Seniority))
+	roleFamilies := pq.Array(nonNilStrings(filter.RoleFamilies))
 
-	var total int
+	var total, activeTotal int
 	if err := s.db.QueryRowContext(
 		ctx,
 		`SELECT 
-			COUNT(*) 
+			COUNT(*),
+			COUNT(*) FILTER (WHERE pj.rejected = FALSE AND pj.closed = FALSE)
 		FROM 
-			jobs`,
+			profile_jobs pj
+		JOIN
+			jobs j ON j.id = pj.job_id
+		WHERE
+			pj.profile_id = $1
+			AND pj.matched`+jobFilterSQL,
+		profileID,
+		seniority,
+		roleFamilies,
+		filter.MaxExperienceYears,
 	).Scan(
 		&total,
-	); err != nil {
-		return nil, 0, 0, err
-	}
-
-	var activeTotal int
-	if err := s.db.QueryRowContext(
-		ctx,
-		`SELECT COUNT(*) FROM jobs WHERE rejected = FALSE AND closed = FALSE`,
-	).Scan(
 		&activeTotal,
 	); err != nil {
 		return nil, 0, 0, err
@@ -167,29 +247,47 @@ func (s *Store) GetJobs(ctx context.Context, limit, offset int) ([]Job, int, int
     		j.title,
     		j.company,
     		j.location,
-    		j.match_score,
-    		j.match_summary,
-    		j.applied,
-    		j.applied_at,
-    		j.rejected,
-    		j.rejected_at,
-    		j.closed,
-    		j.closed_at,
+    		pj.match_score,
+    		COALESCE(pj.match_summary, ''),
+    		pj.applied,
+    		pj.applied_at,
+    		pj.rejected,
+    		pj.rejected_at,
+    		pj.closed,
+    		pj.closed_at,
     		j.posted_at,
     		j.description,
+    		pj.rule_decision,
+    		pj.keyword_breakdown,
+    		COALESCE(j.seniority, ''),
+    		COALESCE(j.experience_years, 0),
+    		COALESCE(j.role_family, ''),
+    		j.facts,
+    		COALESCE(pj.profile_version, 0),
+    		COALESCE(pj.scored_model, ''),
+    		pj.scored_at,
     		j.created_at
 		FROM 
-			jobs j
+			profile_jobs pj
+		JOIN
+			jobs j ON j.id = pj.job_id
 		LEFT JOIN 
 			sources s ON s.id = j.source_id
+		WHERE
+			pj.profile_id = $1
+			AND pj.matched`+jobFilterSQL+`
 		ORDER BY 
-			j.applied ASC, 
-			j.match_score DESC, 
+			pj.applied ASC, 
+			pj.match_score DESC, 
 			COALESCE(j.posted_at, j.created_at) DESC
 		LIMIT 
-			$1 
+			$5 
 		OFFSET 
-			$2`,
+			$6`,
+		profileID,
+		seniority,
+		roleFamilies,
+		filter.MaxExperienceYears,
 		limit,
 		offset,
 	)
@@ -208,6 +306,10 @@ func (s *Store) GetJobs(ctx context.Context, limit, offset int) ([]Job, int, int
 			postedAt   sql.NullTime
 			sourceURL  sql.NullString
 			sourceType sql.NullString
+			decision   []byte
+			keywords   []byte
+			facts      []byte
+			scoredAt   sql.NullTime
 			createdAt  time.Time
 		)
 
@@ -230,11 +332,36 @@ func (s *Store) GetJobs(ctx context.Context, limit, offset int) ([]Job, int, int
 			&closedAt,
 			&postedAt,
 			&j.Description,
+			&decision,
+			&keywords,
+			&j.Seniority,
+			&j.ExperienceYears,
+			&j.RoleFamily,
+			&facts,
+			&j.ProfileVersion,
+			&j.ScoredModel,
+			&scoredAt,
 			&createdAt,
 		); err != nil {
 			return nil, 0, 0, err
 		}
 
+		j.RuleDecision = decodeDecision(decision)
+
+		if len(keywords) > 0 {
+			var k KeywordBreakdown
+			if err := json.Unmarshal(keywords, &k); err == nil {
+				j.Keywords = &k
+			}
+		}
+
+		if len(facts) > 0 {
+			var f JobFacts
+			if err := json.Unmarshal(facts, &f); err == nil {
+				j.Facts = &f
+			}
+		}
+
 		if sourceURL.Valid {
 			j.SourceURL = sourceURL.String
 		}
@@ -247,6 +374,7 @@ func (s *Store) GetJobs(ctx context.Context, limit, offset int) ([]Job, int, int
 		j.RejectedAt = scanNullTime(rejectedAt)
 		j.ClosedAt = scanNullTime(closedAt)
 		j.PostedAt = scanNullTime(postedAt)
+		j.ScoredAt = scanNullTime(scoredAt)
 		j.CreatedAt = createdAt
 
 		jobs = append(jobs, j)
@@ -264,44 +392,16 @@ func (s *Store) ListSources(ctx context.Context, limit, offset int) ([]Source, i
 			COUNT(*) 
 		FROM 
 			sources 
-		WHERE 
-			is_job_site = TRUE
-			AND is_alias = FALSE
-			AND page_type IN ('career_root', 'job_list')
-			AND COALESCE(ats_backed, FALSE) = FALSE`,
+		WHERE `+scrapeEligibleSQL,
 	).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT 
-			id, 
-			url, 
-			COALESCE(normalized_url, ''),
-			COALESCE(host, ''),
-			type, 
-			COALESCE(page_type, ''),
-			is_alias,
-			COALESCE(canonical_url, ''),
-			is_job_site, 
-			tech_related, 
-			confidence, 
-			last_checked_at, 
-			last_scraped_at, 
-			discovered_at, 
-			COALESCE(classification_reason, ''),
-			COALESCE(ats_backed, FALSE),
-			COALESCE(recheck_count, 0),
-			COALESCE(last_error_type, ''),
-			COALESCE(last_error_message, ''),
-			last_error_at
+		SELECT `+sourceColumns+`
 		FROM 
 			sources
-		WHERE 
-			is_job_site = TRUE
-			AND is_alias = FALSE
-			AND page_type IN ('career_root', 'job_list')
-			AND COALESCE(ats_backed, FALSE) = FALSE
+		WHERE `+scrapeEligibleSQL+`
 		ORDER BY 
 			last_scraped_at NULLS FIRST, 
 			last_checked_at NULLS FIRST
@@ -319,48 +419,20 @@ func (s *Store) ListSources(ctx context.Context, limit, offset int) ([]Source, i
 
 	var sources []Source
 	for rows.Next() {
-		var (
-			src          Source
-			lastChecked  sql.NullTime
-			lastScraped  sql.NullTime
-			discoveredAt sql.NullTime
-			lastErrorAt  sql.NullTime
-		)
-
-		if err := rows.Scan(
-			&src.ID,
-			&src.URL,
-			&src.NormalizedURL,
-			&src.Host,
-			&src.Type,
-			&src.PageType,
-			&src.IsAlias,
-			&src.CanonicalURL,
-			&src.IsJobSite,
-			&src.TechRelated,
-			&src.Confidence,
-			&lastChecked,
-			&lastScraped,
-			&discoveredAt,
-			&src.Classification,
-			&src.ATSBacked,
-			&src.RecheckCount,
-			&src.LastErrorType,
-			&src.LastErrorMsg,
-			&lastErrorAt,
-		); err != nil {
+		src, err := scanSource(rows)
+		if err != nil {
 			return nil, 0, err
 		}
-
-		src.LastCheckedAt = scanNullTime(lastChecked)
-		src.LastScrapedAt = scanNullTime(lastScraped)
-		src.DiscoveredAt = scanNullTime(discoveredAt)
-		src.LastErrorAt = scanNullTime(lastErrorAt)
-
-		sources = append(sources, src)
+		sources = append(sources, *src)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
 	}
 
-	return sources, total, rows.Err()
+	if err := s.attachCircuits(ctx, sources); err != nil {
+		return nil, 0, err
+	}
+	return sources, total, nil
 }
 
 func (s *Store) AddSource(
@@ -425,6 +497,7 @@ func (s *Store) AddSource(
 				tech_related = $9,
 				confidence = $10,
 				classification_reason = $11,
+				classification_model = NULL,
 				ats_backed = $12,
 				recheck_count = CASE WHEN $8 THEN 0 ELSE COALESCE(recheck_count, 0) END,
 				last_checked_at = NOW()
@@ -531,6 +604,23 @@ func (s *Store) IncrementSourceRecheck(ctx context.Context, sourceID int) error
 	return err
 }
 
+// SetSourceClassificationModel records which AI model made the source's
+// current classification. AddSource clears it, so call this after it.
+func (s *Store) SetSourceClassificationModel(ctx context.Context, sourceID int, model string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			sources
+		SET
+			classification_model = NULLIF($2, '')
+		WHERE
+			id = $1`,
+		sourceID,
+		model,
+	)
+	return err
+}
+
 func (s *Store) IsHostATSBacked(ctx context.Context, host string) (bool, error) {
 	if strings.TrimSpace(host) == "" {
 		return false, nil
@@ -758,8 +848,132 @@ func (s *Store) ClearSourceError(ctx context.Context, sourceID int) error {
 	return err
 }
 
-func (s *Store) SaveJob(ctx context.Context, job Job) error {
-	_, err := s.db.ExecContext(
+// ProfileMatch is the outcome of scoring a job against one profile.
+type ProfileMatch struct {
+	ProfileID      int
+	ProfileVersion int
+	MatchScore     int
+	MatchSummary   string
+	Keywords       KeywordBreakdown
+	// Model is the AI model that scored the job; empty when the score comes
+	// from keywords alone.
+	Model     string
+	Breakdown *ScoreBreakdown
+	// Matched is false when a filter rule rejected the job. Such rows stay
+	// out of job listings; a job the profile already triaged (applied,
+	// rejected or closed) stays listed.
+	Matched bool
+	// Decision is the filter rule decision for this profile.
+	Decision *RuleDecision
+	// RuleRejected marks a job a rule rejected before scoring. Decision is
+	// stored and the row is unlisted unless triaged; a score the profile
+	// already holds is kept.
+	RuleRejected bool
+}
+
+// ScoreBreakdown explains how a profile's match score was computed: the
+// keyword (rule) score and its terms, the AI verdict, the blend weights and
+// the filter rule boost.
+type ScoreBreakdown struct {
+	Score         int       `json:"score"`
+	RuleScore     int       `json:"rule_score"`
+	MatchedTerms  []TermHit `json:"matched_terms,omitempty"`
+	AIScore       *int      `json:"ai_score,omitempty"`
+	AIFailed      bool      `json:"ai_failed,omitempty"`
+	Strengths     []string  `json:"strengths,omitempty"`
+	Weaknesses    []string  `json:"weaknesses,omitempty"`
+	Summary       string    `json:"summary,omitempty"`
+	RuleWeight    float64   `json:"rule_weight"`
+	AIWeight      float64   `json:"ai_weight"`
+	Boost         int       `json:"boost,omitempty"`
+	Model         string    `json:"model,omitempty"`
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	ScoredAt      time.Time `json:"scored_at"`
+}
+
+// KeywordBreakdown explains a keyword score: the raw points, the normalized
+// 0-100 score and every term that contributed to it.
+type KeywordBreakdown struct {
+	Score int       `json:"score"`
+	Raw   float64   `json:"raw"`
+	Terms []TermHit `json:"terms,omitempty"`
+}
+
+// TermHit is one keyword match. Points are negative for negative keywords.
+type TermHit struct {
+	Term   string  `json:"term"`
+	Field  string  `json:"field"`
+	Points float64 `json:"points"`
+}
+
+// SaveJobMatches upserts a job and its per-profile scores in one transaction.
+// Triage state (applied/rejected/closed) of existing profile rows is preserved,
+// and so are stored facts when job.Facts is nil, together with the seniority
+// and experience an AI reading set.
+func (s *Store) SaveJobMatches(ctx context.Context, job Job, matches []ProfileMatch) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	jobID, err := saveJobMatchesTx(ctx, tx, job, matches)
+	if err != nil {
+		return 0, err
+	}
+	return jobID, tx.Commit()
+}
+
+// JobMatches is a job together with its per-profile scores.
+type JobMatches struct {
+	Job     Job
+	Matches []ProfileMatch
+}
+
+// SaveJobMatchesBatch stores several jobs in one transaction. If the batch
+// fails as a whole it falls back to saving jobs one by one, so a single bad
+// row does not lose the rest; the returned errors are indexed like items.
+func (s *Store) SaveJobMatchesBatch(ctx context.Context, items []JobMatches) []error {
+	errs := make([]error, len(items))
+	if len(items) == 0 {
+		return errs
+	}
+
+	err := func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for _, item := range items {
+			if _, err := saveJobMatchesTx(ctx, tx, item.Job, item.Matches); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	}()
+	if err == nil {
+		return errs
+	}
+
+	for i, item := range items {
+		_, errs[i] = s.SaveJobMatches(ctx, item.Job, item.Matches)
+	}
+	return errs
+}
+
+func saveJobMatchesTx(ctx context.Context, tx *sql.Tx, job Job, matches []ProfileMatch) (int, error) {
+	var facts []byte
+	if job.Facts != nil {
+		encoded, err := json.Marshal(job.Facts)
+		if err != nil {
+			return 0, fmt.Errorf("encode job facts: %w", err)
+		}
+		facts = encoded
+	}
+
+	var jobID int
+	if err := tx.QueryRowContext(
 		ctx,
 		`INSERT INTO
 		    jobs (
@@ -771,19 +985,16 @@ func (s *Store) SaveJob(ctx context.Context, job Job) error {
 		        company,
 		        location,
 		        posted_at,
-		        match_score,
-		        match_summary,
-		        applied,
-		        applied_at,
-		        rejected,
-		        rejected_at,
-		        closed,
-		        closed_at,
+		        content_hash,
+		        seniority,
+		        experience_years,
+		        role_family,
+		        facts,
 		        created_at
 		    )
 		VALUES
 		    (
-		        $1,
+		        NULLIF($1, 0),
 		        $2,
 		        $3,
 		        $4,
@@ -791,14 +1002,11 @@ func (s *Store) SaveJob(ctx context.Context, job Job) error {
 		        $6,
 		        $7,
 		        $8,
-		        $9,
-		        $10,
+		        NULLIF($9, ''),
+		        NULLIF($10, ''),
 		        $11,
-		        $12,
+		        NULLIF($12, ''),
 		        $13,
-		        $14,
-		        $15,
-		        $16,
 		        NOW()
 		    ) ON CONFLICT (url) DO
 		UPDATE
@@ -810,9 +1018,13 @@ func (s *Store) SaveJob(ctx context.Context, job Job) error {
 		    company = EXCLUDED.company,
 		    location = EXCLUDED.location,
 		    posted_at = COALESCE(jobs.posted_at, EXCLUDED.posted_at),
-		    match_score = EXCLUDED.match_score,
-		    match_summary = EXCLUDED.match_summary,
-		    updated_at = NOW()`,
+		    content_hash = EXCLUDED.content_hash,
+		    seniority = CASE WHEN EXCLUDED.facts IS NULL AND jobs.facts ? 'experience_years' THEN jobs.seniority ELSE EXCLUDED.seniority END,
+		    experience_years = CASE WHEN EXCLUDED.facts IS NULL AND jobs.facts ? 'experience_years' THEN jobs.experience_years ELSE EXCLUDED.experience_years END,
+		    role_family = EXCLUDED.role_family,
+		    facts = COALESCE(EXCLUDED.facts, jobs.facts),
+		    updated_at = NOW()
+		RETURNING id`,
 		job.SourceID,
 		job.SourceType,
 		job.URL,
@@ -821,81 +1033,639 @@ func (s *Store) SaveJob(ctx context.Context, job Job) error {
 		job.Company,
 		job.Location,
 		job.PostedAt,
-		job.MatchScore,
-		job.MatchSummary,
-		job.Applied,
-		job.AppliedAt,
-		job.Rejected,
-		job.RejectedAt,
-		job.Closed,
-		job.ClosedAt,
+		job.ContentHash,
+		job.Seniority,
+		job.ExperienceYears,
+		job.RoleFamily,
+		facts,
+	).Scan(&jobID); err != nil {
+		return 0, err
+	}
+
+	for _, m := range matches {
+		if err := saveProfileMatchTx(ctx, tx, jobID, m); err != nil {
+			return 0, err
+		}
+	}
+
+	return jobID, nil
+}
+
+func saveProfileMatchTx(ctx context.Context, tx *sql.Tx, jobID int, m ProfileMatch) error {
+	decision, err := encodeDecision(m.Decision)
+	if err != nil {
+		return err
+	}
+	if m.RuleRejected {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO
+				profile_jobs (
+					profile_id,
+					job_id,
+					rule_decision,
+					matched,
+					created_at,
+					updated_at
+				)
+			VALUES
+				($1, $2, $3, FALSE, NOW(), NOW())
+			ON CONFLICT (profile_id, job_id) DO
+			UPDATE
+			SET
+				rule_decision = EXCLUDED.rule_decision,
+				matched = COALESCE(profile_jobs.applied OR profile_jobs.rejected OR profile_jobs.closed, FALSE),
+				updated_at = NOW()`,
+			m.ProfileID,
+			jobID,
+			decision,
+		)
+		return err
+	}
+
+	keywords, err := json.Marshal(m.Keywords)
+	if err != nil {
+		return fmt.Errorf("encode keyword breakdown: %w", err)
+	}
+	breakdown, err := encodeBreakdown(m.Breakdown)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO
+			profile_jobs (
+				profile_id,
+				job_id,
+				match_score,
+				match_summary,
+				profile_version,
+				keyword_breakdown,
+				scored_model,
+				score_breakdown,
+				matched,
+				rule_decision,
+				scored_at,
+				created_at,
+				updated_at
+			)
+		VALUES
+			($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, NOW(), NOW(), NOW())
+		ON CONFLICT (profile_id, job_id) DO
+		UPDATE
+		SET
+			match_score = EXCLUDED.match_score,
+			match_summary = EXCLUDED.match_summary,
+			profile_version = EXCLUDED.profile_version,
+			keyword_breakdown = EXCLUDED.keyword_breakdown,
+			scored_model = EXCLUDED.scored_model,
+			score_breakdown = EXCLUDED.score_breakdown,
+			matched = EXCLUDED.matched OR COALESCE(profile_jobs.applied OR profile_jobs.rejected OR profile_jobs.closed, FALSE),
+			rule_decision = EXCLUDED.rule_decision,
+			scored_at = NOW(),
+			updated_at = NOW()`,
+		m.ProfileID,
+		jobID,
+		m.MatchScore,
+		m.MatchSummary,
+		m.ProfileVersion,
+		keywords,
+		m.Model,
+		breakdown,
+		m.Matched,
+		decision,
 	)
 	return err
 }
 
-func (s *Store) MarkJobApplied(ctx context.Context, jobID int) error {
-	_, err := s.db.ExecContext(
+// GetJobFingerprints returns the stored fingerprints of the jobs with the given URLs.
+// URLs that are not stored yet are absent from the returned map.
+func (s *Store) GetJobFingerprints(ctx context.Context, urls []string) (map[string]JobFingerprint, error) {
+	out := make(map[string]JobFingerprint, len(urls))
+	if len(urls) == 0 {
+		return out, nil
+	}
+
+	rows, err := s.db.QueryContext(
 		ctx,
-		`UPDATE 
-			jobs
-		SET 
-			applied = TRUE, 
-			applied_at = NOW(),
+		`SELECT
+			j.url,
+			COALESCE(j.content_hash, ''),
+			j.created_at,
+			pj.profile_id,
+			COALESCE(pj.profile_version, 0),
+			COALESCE(pj.scored_model, ''),
+			pj.keyword_breakdown,
+			pj.score_breakdown,
+			pj.rule_decision,
+			COALESCE(pj.matched, FALSE),
+			COALESCE(pj.applied OR pj.rejected OR pj.closed, FALSE)
+		FROM
+			jobs j
+		LEFT JOIN
+			profile_jobs pj ON pj.job_id = j.id
+		WHERE
+			j.url = ANY($1)`,
+		pq.Array(urls),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			jobURL    string
+			hash      string
+			createdAt sql.NullTime
+			profileID sql.NullInt64
+			pf        ProfileFingerprint
+			keywords  []byte
+			breakdown []byte
+			decision  []byte
+		)
+		if err := rows.Scan(
+			&jobURL,
+			&hash,
+			&createdAt,
+			&profileID,
+			&pf.Version,
+			&pf.Model,
+			&keywords,
+			&breakdown,
+			&decision,
+			&pf.Matched,
+			&pf.Triaged,
+		); err != nil {
+			return nil, err
+		}
+		fp, ok := out[jobURL]
+		if !ok {
+			fp = JobFingerprint{ContentHash: hash, Profiles: map[int]ProfileFingerprint{}}
+			if createdAt.Valid {
+				fp.FirstSeen = createdAt.Time
+			}
+		}
+		if profileID.Valid {
+			if len(keywords) > 0 {
+				_ = json.Unmarshal(keywords, &pf.Keywords)
+			}
+			if len(breakdown) > 0 {
+				var b ScoreBreakdown
+				if err := json.Unmarshal(breakdown, &b); err == nil {
+					pf.Breakdown = &b
+				}
+			}
+			pf.Decision = decodeDecision(decision)
+			fp.Profiles[int(profileID.Int64)] = pf
+		}
+		out[jobURL] = fp
+	}
+	return out, rows.Err()
+}
+
+// GetJobByURL returns the posting stored under url, or nil when it does not
+// exist, with everything needed to classify, filter and score it again.
+func (s *Store) GetJobByURL(ctx context.Context, jobURL string) (*Job, error) {
+	var (
+		job       Job
+		postedAt  sql.NullTime
+		createdAt sql.NullTime
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT
+			j.id,
+			COALESCE(j.source_id, 0),
+			COALESCE(s.url, ''),
+			COALESCE(j.source_type, ''),
+			j.url,
+			j.title,
+			COALESCE(j.description, ''),
+			COALESCE(j.company, ''),
+			COALESCE(j.location, ''),
+			j.posted_at,
+			COALESCE(j.content_hash, ''),
+			j.created_at
+		FROM
+			jobs j
+		LEFT JOIN
+			sources s ON s.id = j.source_id
+		WHERE
+			j.url = $1`,
+		jobURL,
+	).Scan(
+		&job.ID,
+		&job.SourceID,
+		&job.SourceURL,
+		&job.SourceType,
+		&job.URL,
+		&job.Title,
+		&job.Description,
+		&job.Company,
+		&job.Location,
+		&postedAt,
+		&job.ContentHash,
+		&createdAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.PostedAt = scanNullTime(postedAt)
+	if createdAt.Valid {
+		job.CreatedAt = createdAt.Time
+	}
+	return &job, nil
+}
+
+// UpdateProfileJobScore replaces the score, summary, model, breakdown and
+// rule decision a profile holds for a job.
+func (s *Store) UpdateProfileJobScore(ctx context.Context, jobID int, m ProfileMatch) error {
+	breakdown, err := encodeBreakdown(m.Breakdown)
+	if err != nil {
+		return err
+	}
+	decision, err := encodeDecision(m.Decision)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			profile_jobs
+		SET
+			match_score = $3,
+			match_summary = $4,
+			scored_model = NULLIF($5, ''),
+			score_breakdown = $6,
+			matched = $7 OR COALESCE(applied OR rejected OR closed, FALSE),
+			rule_decision = $8,
+			scored_at = NOW(),
 			updated_at = NOW()
-		WHERE 
-			id = $1`,
+		WHERE
+			profile_id = $1
+			AND job_id = $2`,
+		m.ProfileID,
 		jobID,
+		m.MatchScore,
+		m.MatchSummary,
+		m.Model,
+		breakdown,
+		m.Matched,
+		decision,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-func (s *Store) MarkJobRejected(ctx context.Context, jobID int) error {
-	_, err := s.db.ExecContext(
+// GetScoreBreakdown returns the breakdown of the score a profile holds for a
+// job, or nil when the profile does not track the job or the score predates
+// breakdowns.
+func (s *Store) GetScoreBreakdown(ctx context.Context, profileID, jobID int) (*ScoreBreakdown, error) {
+	var raw []byte
+	err := s.db.QueryRowContext(
 		ctx,
-		`UPDATE 
-			jobs
-		SET 
-			rejected = TRUE, 
-			rejected_at = NOW(), 
-			updated_at = NOW()
-		WHERE 
-			id = $1`,
+		`SELECT
+			score_breakdown
+		FROM
+			profile_jobs
+		WHERE
+			profile_id = $1
+			AND job_id = $2`,
+		profileID,
 		jobID,
+	).Scan(&raw)
+	if err == sql.ErrNoRows || (err == nil && len(raw) == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b ScoreBreakdown
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return nil, fmt.Errorf("decode score breakdown: %w", err)
+	}
+	return &b, nil
+}
+
+// encodeBreakdown marshals b for a JSONB column; nil stays NULL.
+func encodeBreakdown(b *ScoreBreakdown) (any, error) {
+	if b == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("encode score breakdown: %w", err)
+	}
+	return raw, nil
+}
+
+// encodeDecision marshals d for a JSONB column; nil stays NULL.
+func encodeDecision(d *RuleDecision) (any, error) {
+	if d == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("encode rule decision: %w", err)
+	}
+	return raw, nil
+}
+
+func decodeDecision(raw []byte) *RuleDecision {
+	if len(raw) == 0 {
+		return nil
+	}
+	var d RuleDecision
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil
+	}
+	return &d
+}
+
+// ListJobsToVerify returns open postings that were not checked since
+// verifiedBefore, oldest first. Only id and url are loaded.
+func (s *Store) ListJobsToVerify(ctx context.Context, verifiedBefore time.Time, limit int) ([]Job, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT
+			id,
+			url
+		FROM
+			jobs
+		WHERE
+			COALESCE(closed, FALSE) = FALSE
+			AND COALESCE(verified_at, created_at) < $1
+		ORDER BY
+			COALESCE(verified_at, created_at)
+		LIMIT
+			$2`,
+		verifiedBefore,
+		limit,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(&job.ID, &job.URL); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
 }
 
-func (s *Store) MarkJobClosed(ctx context.Context, jobID int) error {
-	_, err := s.db.ExecContext(
+// JobSelection narrows the stored jobs ListJobsForRescore walks through. Zero
+// values do not filter.
+type JobSelection struct {
+	JobIDs   []int
+	SourceID int
+	// Since keeps jobs first stored at or after it.
+	Since time.Time
+}
+
+// ListJobsForRescore returns open postings with an id above afterID in id
+// order, with everything needed to classify, filter and score them again.
+func (s *Store) ListJobsForRescore(ctx context.Context, sel JobSelection, afterID, limit int) ([]Job, error) {
+	var since *time.Time
+	if !sel.Since.IsZero() {
+		since = &sel.Since
+	}
+
+	rows, err := s.db.QueryContext(
 		ctx,
-		`UPDATE 
+		`SELECT
+			j.id,
+			COALESCE(j.source_id, 0),
+			COALESCE(s.url, ''),
+			COALESCE(j.source_type, ''),
+			j.url,
+			j.title,
+			COALESCE(j.description, ''),
+			COALESCE(j.company, ''),
+			COALESCE(j.location, ''),
+			j.posted_at,
+			COALESCE(j.content_hash, ''),
+			j.created_at
+		FROM
+			jobs j
+		LEFT JOIN
+			sources s ON s.id = j.source_id
+		WHERE
+			j.id > $1
+			AND COALESCE(j.closed, FALSE) = FALSE
+			AND ($2::INT[] IS NULL OR j.id = ANY($2))
+			AND ($3 = 0 OR j.source_id = $3)
+			AND ($4::TIMESTAMPTZ IS NULL OR j.created_at >= $4)
+		ORDER BY
+			j.id
+		LIMIT
+			$5`,
+		afterID,
+		pq.Array(sel.JobIDs),
+		sel.SourceID,
+		since,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var (
+			job       Job
+			postedAt  sql.NullTime
+			createdAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&job.ID,
+			&job.SourceID,
+			&job.SourceURL,
+			&job.SourceType,
+			&job.URL,
+			&job.Title,
+			&job.Description,
+			&job.Company,
+			&job.Location,
+			&postedAt,
+			&job.ContentHash,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		job.PostedAt = scanNullTime(postedAt)
+		if createdAt.Valid {
+			job.CreatedAt = createdAt.Time
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// MarkJobVerified records a liveness check of a posting. A closed posting is
+// also closed for every profile that tracks it.
+func (s *Store) MarkJobVerified(ctx context.Context, jobID int, closed bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE
 			jobs
-		SET 
-			closed = TRUE, 
-			closed_at = NOW(), 
-			updated_at = NOW()
-		WHERE 
+		SET
+			verified_at = NOW(),
+			closed = COALESCE(closed, FALSE) OR $2,
+			closed_at = CASE WHEN $2 AND NOT COALESCE(closed, FALSE) THEN NOW() ELSE closed_at END
+		WHERE
 			id = $1`,
 		jobID,
+		closed,
+	); err != nil {
+		return err
+	}
+	if closed {
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE
+				profile_jobs
+			SET
+				closed = TRUE,
+				closed_at = COALESCE(closed_at, NOW()),
+				updated_at = NOW()
+			WHERE
+				job_id = $1`,
+			jobID,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// MarkJobApplied, MarkJobRejected and MarkJobClosed record triage state for a
+// single profile; the row is created if the job was never scored for it.
+// Triaging a job also lists it for the profile.
+func (s *Store) MarkJobApplied(ctx context.Context, profileID, jobID int) error {
+	return s.markProfileJob(
+		ctx,
+		`INSERT INTO
+			profile_jobs (
+				profile_id,
+				job_id,
+				applied,
+				applied_at,
+				matched,
+				created_at,
+				updated_at
+			)
+		SELECT
+			$1, id, TRUE, NOW(), TRUE, NOW(), NOW()
+		FROM
+			jobs
+		WHERE
+			id = $2
+		ON CONFLICT (profile_id, job_id) DO
+		UPDATE
+		SET
+			applied = TRUE,
+			applied_at = NOW(),
+			matched = TRUE,
+			updated_at = NOW()`,
+		profileID,
+		jobID,
 	)
-	return err
 }
 
-func (s *Store) DeleteOldJobs(ctx context.Context, olderThan time.Duration) (int64, error) {
-	cutoff := time.Now().Add(-olderThan)
+func (s *Store) MarkJobRejected(ctx context.Context, profileID, jobID int) error {
+	return s.markProfileJob(
+		ctx,
+		`INSERT INTO
+			profile_jobs (
+				profile_id,
+				job_id,
+				rejected,
+				rejected_at,
+				matched,
+				created_at,
+				updated_at
+			)
+		SELECT
+			$1, id, TRUE, NOW(), TRUE, NOW(), NOW()
+		FROM
+			jobs
+		WHERE
+			id = $2
+		ON CONFLICT (profile_id, job_id) DO
+		UPDATE
+		SET
+			rejected = TRUE,
+			rejected_at = NOW(),
+			matched = TRUE,
+			updated_at = NOW()`,
+		profileID,
+		jobID,
+	)
+}
 
-	res, err := s.db.ExecContext(
+func (s *Store) MarkJobClosed(ctx context.Context, profileID, jobID int) error {
+	return s.markProfileJob(
 		ctx,
-		`DELETE FROM 
+		`INSERT INTO
+			profile_jobs (
+				profile_id,
+				job_id,
+				closed,
+				closed_at,
+				matched,
+				created_at,
+				updated_at
+			)
+		SELECT
+			$1, id, TRUE, NOW(), TRUE, NOW(), NOW()
+		FROM
 			jobs
-		WHERE 
-			COALESCE(posted_at, created_at) < $1`,
-		cutoff,
+		WHERE
+			id = $2
+		ON CONFLICT (profile_id, job_id) DO
+		UPDATE
+		SET
+			closed = TRUE,
+			closed_at = NOW(),
+			matched = TRUE,
+			updated_at = NOW()`,
+		profileID,
+		jobID,
 	)
+}
+
+// markProfileJob runs one of the triage upserts and reports ErrNotFound when
+// the job does not exist.
+func (s *Store) markProfileJob(ctx context.Context, query string, profileID, jobID int) error {
+	res, err := s.db.ExecContext(ctx, query, profileID, jobID)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	return res.RowsAffected()
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
 }
 
 func (s *Store) GetStatsCounts(ctx context.Context) (sourcesTotal, jobsTotal, activeJobs int, err error) {
@@ -915,7 +1685,7 @@ func (s *Store) GetStatsCounts(ctx context.Context) (sourcesTotal, jobsTotal, ac
 
 	if err = s.db.QueryRowContext(
 		ctx,
-		`SELECT COUNT(*) FROM jobs WHERE rejected = FALSE AND closed = FALSE`,
+		`SELECT COUNT(DISTINCT job_id) FROM profile_jobs WHERE matched AND rejected = FALSE AND closed = FALSE`,
 	).Scan(&activeJobs); err != nil {
 		return 0, 0, 0, err
 	}
@@ -0,0 +1,32 @@
+package store
+
+// FactSourceScraper marks a fact the job board published itself.
+const FactSourceScraper = "scraper"
+
+// JobFact is one structured detail of a posting. Source is FactSourceScraper
+// or the model ("heuristic" for the rule-based extractor) that read it from
+// the description; Confidence runs from 0 to 1 and is 1 for scraper data.
+type JobFact[T any] struct {
+	Value      T       `json:"value"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+type Salary struct {
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Currency string `json:"currency,omitempty"`
+	Period   string `json:"period"`
+}
+
+// JobFacts are the structured details stored with a job. Facts nobody could
+// tell are nil. ExperienceYears is only set when a confident AI reading
+// replaced the classifier's value in Job.ExperienceYears, and always equals it.
+type JobFacts struct {
+	Salary          *JobFact[Salary]   `json:"salary,omitempty"`
+	VisaSponsorship *JobFact[bool]     `json:"visa_sponsorship,omitempty"`
+	RemoteRegions   *JobFact[[]string] `json:"remote_regions,omitempty"`
+	ExperienceYears *JobFact[int]      `json:"experience_years,omitempty"`
+	TechStack       *JobFact[[]string] `json:"tech_stack,omitempty"`
+	EmploymentType  *JobFact[string]   `json:"employment_type,omitempty"`
+}
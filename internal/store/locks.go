@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+)
+
+// AdvisoryLock is a session-level Postgres advisory lock. Postgres ties the
+// lock to one connection, so the lock pins a pooled connection until Release;
+// if that connection dies the lock is gone and Alive reports an error.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// AdvisoryLockKey maps a lock name to the 64-bit key Postgres locks on.
+func AdvisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("job-hunter:" + name))
+	return int64(h.Sum64())
+}
+
+// TryAdvisoryLock takes the named lock without waiting. It returns nil, nil
+// when another session holds it.
+func (s *Store) TryAdvisoryLock(ctx context.Context, name string) (*AdvisoryLock, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := AdvisoryLockKey(name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Alive checks that the session holding the lock still exists and still owns it.
+func (l *AdvisoryLock) Alive(ctx context.Context) error {
+	var held bool
+	err := l.conn.QueryRowContext(ctx, `
+		SELECT
+			EXISTS (
+				SELECT
+					1
+				FROM
+					pg_locks
+				WHERE
+					locktype = 'advisory'
+					AND pid = pg_backend_pid()
+					AND granted
+					AND objsubid = 1
+					AND ((classid::bigint << 32) | objid::bigint) = $1
+			)`,
+		l.key,
+	).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Release unlocks and returns the pinned connection to the pool.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key)
+	if closeErr := l.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultProfileName is the profile used when no profile is named explicitly.
+const DefaultProfileName = "default"
+
+// Profile describes what the candidate is looking for. Version is bumped every
+// time the settings change so derived data (scores) can be invalidated.
+type Profile struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Version   int        `json:"version"`
+	Active    bool       `json:"active"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	ProfileSettings
+}
+
+// ProfileSettings is the editable part of a profile, persisted as JSONB.
+type ProfileSettings struct {
+	TechStack       []string       `json:"tech_stack"`
+	IncludeKeywords []string       `json:"include_keywords"`
+	ExcludeKeywords []string       `json:"exclude_keywords"`
+	Location        LocationPolicy `json:"location"`
+	Seniority       []string       `json:"seniority"`
+	RoleFamilies    []string       `json:"role_families"`
+	// MaxExperienceYears drops postings that ask for more years; 0 disables it.
+	MaxExperienceYears int            `json:"max_experience_years"`
+	SalaryFloor        int            `json:"salary_floor"`
+	Preferences        string         `json:"preferences"`
+	Scoring            ScoringWeights `json:"scoring"`
+}
+
+// ScoringWeights tunes keyword scoring. Zero weights fall back to the scorer's
+// defaults; weighted keywords missing from include_keywords are scored too.
+type ScoringWeights struct {
+	TitleWeight       float64            `json:"title_weight,omitempty"`
+	DescriptionWeight float64            `json:"description_weight,omitempty"`
+	Keywords          map[string]float64 `json:"keywords,omitempty"`
+	Negative          map[string]float64 `json:"negative,omitempty"`
+}
+
+type LocationPolicy struct {
+	RemoteOnly bool     `json:"remote_only"`
+	Allowed    []string `json:"allowed"`
+	Blocked    []string `json:"blocked"`
+}
+
+const profileColumns = `
+	id,
+	name,
+	version,
+	COALESCE(active, TRUE),
+	data,
+	updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProfile(row rowScanner) (*Profile, error) {
+	var (
+		p         Profile
+		data      []byte
+		updatedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&p.ID,
+		&p.Name,
+		&p.Version,
+		&p.Active,
+		&data,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p.ProfileSettings); err != nil {
+		return nil, fmt.Errorf("decode profile %q: %w", p.Name, err)
+	}
+	p.UpdatedAt = scanNullTime(updatedAt)
+	return &p, nil
+}
+
+func (s *Store) GetProfile(ctx context.Context, name string) (*Profile, error) {
+	p, err := scanProfile(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+profileColumns+`
+		FROM
+			profiles
+		WHERE
+			name = $1`,
+		name,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// ListProfiles returns profiles ordered by name, optionally only active ones.
+func (s *Store) ListProfiles(ctx context.Context, activeOnly bool) ([]Profile, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+profileColumns+`
+		FROM
+			profiles
+		WHERE
+			$1 = FALSE
+			OR COALESCE(active, TRUE) = TRUE
+		ORDER BY
+			name`,
+		activeOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []Profile
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+// SaveProfile upserts a profile by name. The version is only bumped when the
+// settings actually differ from what is stored.
+func (s *Store) SaveProfile(ctx context.Context, p Profile) (*Profile, error) {
+	data, err := json.Marshal(p.ProfileSettings)
+	if err != nil {
+		return nil, fmt.Errorf("encode profile %q: %w", p.Name, err)
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO
+			profiles (
+				name,
+				data,
+				version,
+				active,
+				updated_at
+			)
+		VALUES
+			($1, $2, 1, $3, NOW())
+		ON CONFLICT (name) DO
+		UPDATE
+		SET
+			data = EXCLUDED.data,
+			active = EXCLUDED.active,
+			version = CASE
+				WHEN profiles.data IS DISTINCT FROM EXCLUDED.data THEN profiles.version + 1
+				ELSE profiles.version
+			END,
+			updated_at = NOW()`,
+		p.Name,
+		data,
+		p.Active,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetProfile(ctx, p.Name)
+}
+
+// MigrateLegacyJobState copies the scores and triage state that predate
+// profiles (jobs.match_score, match_summary, applied and rejected) into
+// profileID's rows and drops those columns, so profile_jobs is the only
+// source of truth. It returns the number of rows copied and does nothing once
+// the columns are gone.
+func (s *Store) MigrateLegacyJobState(ctx context.Context, profileID int) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Serializes replicas starting together; the loser sees no columns left.
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE jobs IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return 0, err
+	}
+	var legacy bool
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+			SELECT
+				1
+			FROM
+				information_schema.columns
+			WHERE
+				table_schema = current_schema()
+				AND table_name = 'jobs'
+				AND column_name = 'match_score'
+		)`,
+	).Scan(&legacy); err != nil {
+		return 0, err
+	}
+	if !legacy {
+		return 0, nil
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO
+			profile_jobs (
+				profile_id,
+				job_id,
+				match_score,
+				match_summary,
+				profile_version,
+				applied,
+				applied_at,
+				rejected,
+				rejected_at,
+				closed,
+				closed_at
+			)
+		SELECT
+			$1,
+			id,
+			COALESCE(match_score, 0),
+			match_summary,
+			0,
+			COALESCE(applied, FALSE),
+			applied_at,
+			COALESCE(rejected, FALSE),
+			rejected_at,
+			COALESCE(closed, FALSE),
+			closed_at
+		FROM
+			jobs
+		ON CONFLICT (profile_id, job_id) DO NOTHING`,
+		profileID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	copied, _ := res.RowsAffected()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`ALTER TABLE jobs
+			DROP COLUMN IF EXISTS match_score,
+			DROP COLUMN IF EXISTS match_summary,
+			DROP COLUMN IF EXISTS applied,
+			DROP COLUMN IF EXISTS applied_at,
+			DROP COLUMN IF EXISTS rejected,
+			DROP COLUMN IF EXISTS rejected_at`,
+	); err != nil {
+		return 0, err
+	}
+	return copied, tx.Commit()
+}
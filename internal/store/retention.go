@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Retention states. A job's state is derived from every profile that tracks
+// it, most protective first: applied by any profile, untouched (some profile
+// has not triaged it and the posting is open), closed, and finally rejected
+// by every profile that tracks it.
+const (
+	RetentionApplied   = "applied"
+	RetentionUntouched = "untouched"
+	RetentionClosed    = "closed"
+	RetentionRejected  = "rejected"
+)
+
+// RetentionPolicy is how long jobs are kept in each state. Rejected and
+// closed jobs age from when they were rejected or closed, untouched ones from
+// when they were posted. A zero window keeps jobs of that state forever;
+// applied jobs are always kept.
+type RetentionPolicy struct {
+	Untouched time.Duration
+	Closed    time.Duration
+	Rejected  time.Duration
+}
+
+// ExpiredJob is a job past the retention window of its state.
+type ExpiredJob struct {
+	ID      int       `json:"id"`
+	URL     string    `json:"url"`
+	Title   string    `json:"title"`
+	Company string    `json:"company"`
+	State   string    `json:"state"`
+	Since   time.Time `json:"since"`
+}
+
+// expiredJobsSQL selects the jobs past their state's retention window. It
+// takes the windows in seconds as $1 untouched, $2 closed and $3 rejected.
+const expiredJobsSQL = `
+	WITH job_states AS (
+		SELECT
+			j.id,
+			j.url,
+			j.title,
+			COALESCE(j.company, '') AS company,
+			CASE
+				WHEN BOOL_OR(COALESCE(pj.applied, FALSE)) THEN 'applied'
+				WHEN NOT COALESCE(j.closed, FALSE)
+					AND (COUNT(pj.job_id) = 0 OR BOOL_OR(NOT COALESCE(pj.rejected, FALSE) AND NOT COALESCE(pj.closed, FALSE)))
+					THEN 'untouched'
+				WHEN COALESCE(j.closed, FALSE) OR BOOL_OR(COALESCE(pj.closed, FALSE)) THEN 'closed'
+				ELSE 'rejected'
+			END AS state,
+			COALESCE(j.posted_at, j.created_at) AS seen_at,
+			GREATEST(j.closed_at, MAX(pj.closed_at)) AS closed_at,
+			MAX(pj.rejected_at) AS rejected_at
+		FROM
+			jobs j
+		LEFT JOIN
+			profile_jobs pj ON pj.job_id = j.id
+		GROUP BY
+			j.id
+	),
+	aged AS (
+		SELECT
+			id,
+			url,
+			title,
+			company,
+			state,
+			CASE state
+				WHEN 'closed' THEN COALESCE(closed_at, seen_at)
+				WHEN 'rejected' THEN COALESCE(rejected_at, seen_at)
+				ELSE seen_at
+			END AS since,
+			CASE state
+				WHEN 'applied' THEN 0
+				WHEN 'untouched' THEN $1::DOUBLE PRECISION
+				WHEN 'closed' THEN $2::DOUBLE PRECISION
+				ELSE $3::DOUBLE PRECISION
+			END AS window_secs
+		FROM
+			job_states
+	),
+	expired AS (
+		SELECT
+			id,
+			url,
+			title,
+			company,
+			state,
+			since
+		FROM
+			aged
+		WHERE
+			window_secs > 0
+			AND since < NOW() - make_interval(secs => window_secs)
+	)`
+
+func retentionArgs(policy RetentionPolicy) []any {
+	return []any{
+		policy.Untouched.Seconds(),
+		policy.Closed.Seconds(),
+		policy.Rejected.Seconds(),
+	}
+}
+
+func scanStateCounts(rows *sql.Rows) (map[string]int, error) {
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var (
+			state string
+			n     int
+		)
+		if err := rows.Scan(&state, &n); err != nil {
+			return nil, err
+		}
+		counts[state] = n
+	}
+	return counts, rows.Err()
+}
+
+func scanExpiredJobs(rows *sql.Rows) ([]ExpiredJob, error) {
+	defer rows.Close()
+	var jobs []ExpiredJob
+	for rows.Next() {
+		var j ExpiredJob
+		if err := rows.Scan(&j.ID, &j.URL, &j.Title, &j.Company, &j.State, &j.Since); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// PreviewExpiredJobs reports what DeleteExpiredJobs would remove without
+// deleting anything: the count per state and up to limit jobs, oldest first.
+func (s *Store) PreviewExpiredJobs(ctx context.Context, policy RetentionPolicy, limit int) ([]ExpiredJob, map[string]int, error) {
+	args := retentionArgs(policy)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		expiredJobsSQL+`
+		SELECT
+			state,
+			COUNT(*)
+		FROM
+			expired
+		GROUP BY
+			state`,
+		args...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts, err := scanStateCounts(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = s.db.QueryContext(
+		ctx,
+		expiredJobsSQL+`
+		SELECT
+			id,
+			url,
+			title,
+			company,
+			state,
+			since
+		FROM
+			expired
+		ORDER BY
+			since,
+			id
+		LIMIT
+			$4`,
+		append(args, clampLimit(limit, 20, 200))...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	jobs, err := scanExpiredJobs(rows)
+	return jobs, counts, err
+}
+
+// DeleteExpiredJobs deletes the jobs past their state's retention window and
+// returns how many it deleted per state. Profile scores and triage state go
+// with them.
+func (s *Store) DeleteExpiredJobs(ctx context.Context, policy RetentionPolicy) (map[string]int, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		expiredJobsSQL+`,
+	deleted AS (
+		DELETE FROM
+			jobs j
+		USING
+			expired e
+		WHERE
+			j.id = e.id
+		RETURNING
+			e.state
+	)
+		SELECT
+			state,
+			COUNT(*)
+		FROM
+			deleted
+		GROUP BY
+			state`,
+		retentionArgs(policy)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanStateCounts(rows)
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// FilterRule is a stored ingestion rule. Rules are evaluated by ascending
+// position, then id.
+type FilterRule struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Expression string     `json:"expression"`
+	Action     string     `json:"action"`
+	Boost      int        `json:"boost"`
+	Position   int        `json:"position"`
+	Enabled    bool       `json:"enabled"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// RuleDecision records which rule decided a job during ingestion.
+type RuleDecision struct {
+	Action   string   `json:"action,omitempty"`
+	RuleID   int      `json:"rule_id,omitempty"`
+	RuleName string   `json:"rule_name,omitempty"`
+	Boost    int      `json:"boost,omitempty"`
+	Boosts   []string `json:"boosts,omitempty"`
+}
+
+const filterRuleColumns = `
+	id,
+	name,
+	expression,
+	action,
+	boost,
+	position,
+	enabled,
+	created_at,
+	updated_at`
+
+func scanFilterRule(row rowScanner) (*FilterRule, error) {
+	var (
+		r         FilterRule
+		createdAt sql.NullTime
+		updatedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&r.ID,
+		&r.Name,
+		&r.Expression,
+		&r.Action,
+		&r.Boost,
+		&r.Position,
+		&r.Enabled,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	r.CreatedAt = scanNullTime(createdAt)
+	r.UpdatedAt = scanNullTime(updatedAt)
+	return &r, nil
+}
+
+func (s *Store) ListFilterRules(ctx context.Context, enabledOnly bool) ([]FilterRule, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+filterRuleColumns+`
+		FROM
+			filter_rules
+		WHERE
+			$1 = FALSE
+			OR enabled = TRUE
+		ORDER BY
+			position,
+			id`,
+		enabledOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FilterRule
+	for rows.Next() {
+		r, err := scanFilterRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetFilterRule(ctx context.Context, id int) (*FilterRule, error) {
+	r, err := scanFilterRule(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+filterRuleColumns+`
+		FROM
+			filter_rules
+		WHERE
+			id = $1`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func (s *Store) CreateFilterRule(ctx context.Context, r FilterRule) (*FilterRule, error) {
+	return scanFilterRule(s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			filter_rules (
+				name,
+				expression,
+				action,
+				boost,
+				position,
+				enabled,
+				created_at,
+				updated_at
+			)
+		VALUES
+			($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING `+filterRuleColumns,
+		r.Name,
+		r.Expression,
+		r.Action,
+		r.Boost,
+		r.Position,
+		r.Enabled,
+	))
+}
+
+func (s *Store) UpdateFilterRule(ctx context.Context, r FilterRule) (*FilterRule, error) {
+	updated, err := scanFilterRule(s.db.QueryRowContext(
+		ctx,
+		`UPDATE
+			filter_rules
+		SET
+			name = $1,
+			expression = $2,
+			action = $3,
+			boost = $4,
+			position = $5,
+			enabled = $6,
+			updated_at = NOW()
+		WHERE
+			id = $7
+		RETURNING `+filterRuleColumns,
+		r.Name,
+		r.Expression,
+		r.Action,
+		r.Boost,
+		r.Position,
+		r.Enabled,
+		r.ID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return updated, err
+}
+
+func (s *Store) DeleteFilterRule(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM
+			filter_rules
+		WHERE
+			id = $1`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
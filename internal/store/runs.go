@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	RunKindIngestion    = "ingestion"
+	RunKindSourceScrape = "source_scrape"
+	RunKindDiscovery    = "discovery"
+	RunKindRescore      = "rescore"
+
+	RunTriggerManual   = "manual"
+	RunTriggerSchedule = "schedule"
+
+	RunQueued    = "queued"
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+// staleRunAfter is how long an active run may go without a progress update
+// before a new trigger treats it as abandoned (e.g. the process died).
+const staleRunAfter = 30 * time.Minute
+
+// Run is one ingestion, single-source scrape, discovery or rescore execution.
+type Run struct {
+	ID         int64      `json:"id"`
+	Kind       string     `json:"kind"`
+	SourceID   int        `json:"source_id,omitempty"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	Processed  int        `json:"processed"`
+	Skipped    int        `json:"skipped"`
+	JobsSaved  int        `json:"jobs_saved"`
+	ErrorCount int        `json:"error_count"`
+	Errors     []string   `json:"errors,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// RunProgress is the counter state written while a run executes.
+type RunProgress struct {
+	Processed  int
+	Skipped    int
+	JobsSaved  int
+	ErrorCount int
+	Errors     []string
+}
+
+const runColumns = `
+	id,
+	kind,
+	COALESCE(source_id, 0),
+	trigger,
+	status,
+	processed,
+	skipped,
+	jobs_saved,
+	error_count,
+	errors,
+	started_at,
+	finished_at,
+	created_at,
+	updated_at`
+
+func scanRun(row rowScanner) (*Run, error) {
+	var (
+		r          Run
+		errs       []byte
+		startedAt  sql.NullTime
+		finishedAt sql.NullTime
+		createdAt  sql.NullTime
+		updatedAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&r.ID,
+		&r.Kind,
+		&r.SourceID,
+		&r.Trigger,
+		&r.Status,
+		&r.Processed,
+		&r.Skipped,
+		&r.JobsSaved,
+		&r.ErrorCount,
+		&errs,
+		&startedAt,
+		&finishedAt,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		_ = json.Unmarshal(errs, &r.Errors)
+	}
+	r.StartedAt = scanNullTime(startedAt)
+	r.FinishedAt = scanNullTime(finishedAt)
+	r.CreatedAt = scanNullTime(createdAt)
+	r.UpdatedAt = scanNullTime(updatedAt)
+	return &r, nil
+}
+
+// CreateRun queues a run. If a run of the same kind (and source) is already
+// queued or running, that run is returned instead and created is false. A
+// source scrape also coalesces onto an active ingestion run, which covers
+// every source.
+func (s *Store) CreateRun(ctx context.Context, kind string, sourceID int, trigger string) (*Run, bool, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			runs
+		SET
+			status = 'failed',
+			errors = '["abandoned without progress"]'::jsonb,
+			error_count = error_count + 1,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE
+			(
+				(kind = $1 AND COALESCE(source_id, 0) = $2)
+				OR ($1 = 'source_scrape' AND kind = 'ingestion')
+			)
+			AND status IN ('queued', 'running')
+			AND updated_at < NOW() - make_interval(secs => $3)`,
+		kind,
+		sourceID,
+		staleRunAfter.Seconds(),
+	); err != nil {
+		return nil, false, err
+	}
+
+	if kind == RunKindSourceScrape {
+		run, err := s.activeRun(ctx, RunKindIngestion, 0)
+		if err != sql.ErrNoRows {
+			return run, false, err
+		}
+	}
+
+	run, err := scanRun(s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			runs (kind, source_id, trigger, status, created_at, updated_at)
+		VALUES
+			($1, NULLIF($2, 0), $3, 'queued', NOW(), NOW())
+		ON CONFLICT (kind, COALESCE(source_id, 0)) WHERE status IN ('queued', 'running') DO NOTHING
+		RETURNING `+runColumns,
+		kind,
+		sourceID,
+		trigger,
+	))
+	if err == nil {
+		return run, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	run, err = s.activeRun(ctx, kind, sourceID)
+	if err == sql.ErrNoRows {
+		// The active run finished between the insert and the lookup; try once more.
+		return s.CreateRun(ctx, kind, sourceID, trigger)
+	}
+	return run, false, err
+}
+
+func (s *Store) activeRun(ctx context.Context, kind string, sourceID int) (*Run, error) {
+	return scanRun(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+runColumns+`
+		FROM
+			runs
+		WHERE
+			kind = $1
+			AND COALESCE(source_id, 0) = $2
+			AND status IN ('queued', 'running')`,
+		kind,
+		sourceID,
+	))
+}
+
+// GetRun returns a run by id, or nil when it does not exist.
+func (s *Store) GetRun(ctx context.Context, id int64) (*Run, error) {
+	run, err := scanRun(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+runColumns+`
+		FROM
+			runs
+		WHERE
+			id = $1`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return run, err
+}
+
+func (s *Store) StartRun(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			runs
+		SET
+			status = 'running',
+			started_at = NOW(),
+			updated_at = NOW()
+		WHERE
+			id = $1`,
+		id,
+	)
+	return err
+}
+
+// UpdateRun writes progress and, when status is a final state, finishes the run.
+func (s *Store) UpdateRun(ctx context.Context, id int64, status string, progress RunProgress) error {
+	errs, err := json.Marshal(progress.Errors)
+	if err != nil {
+		return fmt.Errorf("encode run errors: %w", err)
+	}
+	_, err = s.db.ExecContext(
+		ctx,
+		`UPDATE
+			runs
+		SET
+			status = $1,
+			processed = $2,
+			skipped = $3,
+			jobs_saved = $4,
+			error_count = $5,
+			errors = $6,
+			finished_at = CASE WHEN $1 IN ('succeeded', 'failed', 'cancelled') THEN NOW() ELSE finished_at END,
+			updated_at = NOW()
+		WHERE
+			id = $7`,
+		status,
+		progress.Processed,
+		progress.Skipped,
+		progress.JobsSaved,
+		progress.ErrorCount,
+		errs,
+		id,
+	)
+	return err
+}
+
+// DeleteFinishedRuns removes runs that finished before olderThan. Active runs
+// are never removed.
+func (s *Store) DeleteFinishedRuns(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM
+			runs
+		WHERE
+			status IN ('succeeded', 'failed', 'cancelled')
+			AND finished_at < $1`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	ScheduleSucceeded = "succeeded"
+	ScheduleFailed    = "failed"
+	ScheduleCancelled = "cancelled"
+)
+
+// Schedule is the stored state of one scheduled background job.
+type Schedule struct {
+	Name           string     `json:"name"`
+	Spec           string     `json:"spec"`
+	JitterSeconds  int        `json:"jitter_seconds"`
+	Paused         bool       `json:"paused"`
+	Running        bool       `json:"running"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMS int64      `json:"last_duration_ms,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	Runs           int        `json:"runs"`
+	Failures       int        `json:"failures"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+const scheduleColumns = `
+	name,
+	spec,
+	jitter_seconds,
+	paused,
+	running,
+	last_run_at,
+	COALESCE(last_duration_ms, 0),
+	COALESCE(last_status, ''),
+	COALESCE(last_error, ''),
+	last_error_at,
+	next_run_at,
+	runs,
+	failures,
+	updated_at`
+
+func scanSchedule(row rowScanner) (*Schedule, error) {
+	var (
+		sc          Schedule
+		lastRunAt   sql.NullTime
+		lastErrorAt sql.NullTime
+		nextRunAt   sql.NullTime
+		updatedAt   sql.NullTime
+	)
+	if err := row.Scan(
+		&sc.Name,
+		&sc.Spec,
+		&sc.JitterSeconds,
+		&sc.Paused,
+		&sc.Running,
+		&lastRunAt,
+		&sc.LastDurationMS,
+		&sc.LastStatus,
+		&sc.LastError,
+		&lastErrorAt,
+		&nextRunAt,
+		&sc.Runs,
+		&sc.Failures,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	sc.LastRunAt = scanNullTime(lastRunAt)
+	sc.LastErrorAt = scanNullTime(lastErrorAt)
+	sc.NextRunAt = scanNullTime(nextRunAt)
+	sc.UpdatedAt = scanNullTime(updatedAt)
+	return &sc, nil
+}
+
+// UpsertSchedule records a scheduled job and its current spec, keeping the
+// paused flag and run history of an existing row.
+func (s *Store) UpsertSchedule(ctx context.Context, name, spec string, jitter time.Duration) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO
+			schedules (name, spec, jitter_seconds, updated_at)
+		VALUES
+			($1, $2, $3, NOW())
+		ON CONFLICT (name) DO
+		UPDATE
+		SET
+			spec = EXCLUDED.spec,
+			jitter_seconds = EXCLUDED.jitter_seconds,
+			updated_at = NOW()`,
+		name,
+		spec,
+		int(jitter.Seconds()),
+	)
+	return err
+}
+
+func (s *Store) ListSchedules(ctx context.Context) ([]Schedule, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+scheduleColumns+`
+		FROM
+			schedules
+		ORDER BY
+			name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []Schedule
+	for rows.Next() {
+		sc, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *sc)
+	}
+	return schedules, rows.Err()
+}
+
+// GetSchedule returns the named schedule, or nil when it does not exist.
+func (s *Store) GetSchedule(ctx context.Context, name string) (*Schedule, error) {
+	sc, err := scanSchedule(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+scheduleColumns+`
+		FROM
+			schedules
+		WHERE
+			name = $1`,
+		name,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sc, err
+}
+
+// SetSchedulePaused pauses or resumes a schedule. A paused job keeps its
+// timer but skips its runs.
+func (s *Store) SetSchedulePaused(ctx context.Context, name string, paused bool) (*Schedule, error) {
+	sc, err := scanSchedule(s.db.QueryRowContext(
+		ctx,
+		`UPDATE
+			schedules
+		SET
+			paused = $2,
+			updated_at = NOW()
+		WHERE
+			name = $1
+		RETURNING `+scheduleColumns,
+		name,
+		paused,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return sc, err
+}
+
+// SetScheduleNextRun records when the job runs next. It also clears the
+// running flag: the caller holds the job's lease, so a flag left behind by a
+// replica that died mid-run is stale.
+func (s *Store) SetScheduleNextRun(ctx context.Context, name string, next time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			schedules
+		SET
+			next_run_at = $2,
+			running = FALSE,
+			updated_at = NOW()
+		WHERE
+			name = $1`,
+		name,
+		next,
+	)
+	return err
+}
+
+func (s *Store) MarkScheduleStarted(ctx context.Context, name string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			schedules
+		SET
+			running = TRUE,
+			last_run_at = NOW(),
+			updated_at = NOW()
+		WHERE
+			name = $1`,
+		name,
+	)
+	return err
+}
+
+// MarkScheduleFinished records the outcome of a run. The last error is kept
+// after later successes so it stays visible.
+func (s *Store) MarkScheduleFinished(ctx context.Context, name, status string, duration time.Duration, message string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			schedules
+		SET
+			running = FALSE,
+			last_status = $2,
+			last_duration_ms = $3,
+			last_error = CASE WHEN $4 = '' THEN last_error ELSE $4 END,
+			last_error_at = CASE WHEN $4 = '' THEN last_error_at ELSE NOW() END,
+			runs = runs + 1,
+			failures = failures + CASE WHEN $2 = 'failed' THEN 1 ELSE 0 END,
+			updated_at = NOW()
+		WHERE
+			name = $1`,
+		name,
+		status,
+		duration.Milliseconds(),
+		message,
+	)
+	return err
+}
@@ -8,6 +8,7 @@ CREATE TABLE IF NOT EXISTS sources (
     tech_related BOOLEAN DEFAULT FALSE,
     confidence FLOAT DEFAULT 0,
     classification_reason TEXT,
+    classification_model TEXT,
     page_type TEXT DEFAULT 'non_job',
     is_alias BOOLEAN DEFAULT FALSE,
     canonical_url TEXT,
@@ -33,14 +34,8 @@ CREATE TABLE IF NOT EXISTS jobs (
     location TEXT,
     salary_range TEXT,
     posted_at TIMESTAMP WITH TIME ZONE,
-    match_score INT DEFAULT 0,
-    match_summary TEXT, -- JSON or text summary
-    applied BOOLEAN DEFAULT FALSE,
-    rejected BOOLEAN DEFAULT FALSE,
     closed BOOLEAN DEFAULT FALSE,
     closed_at TIMESTAMP WITH TIME ZONE,
-    rejected_at TIMESTAMP WITH TIME ZONE,
-    applied_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
@@ -63,9 +58,20 @@ CREATE TABLE IF NOT EXISTS stats_snapshots (
     active_jobs BIGINT
 );
 
+CREATE TABLE IF NOT EXISTS profiles (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    active BOOLEAN DEFAULT TRUE,
+    data JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS last_scraped_at TIMESTAMP WITH TIME ZONE;
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS discovered_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS classification_reason TEXT;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS classification_model TEXT;
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS host TEXT;
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS normalized_url TEXT;
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS page_type TEXT DEFAULT 'non_job';
@@ -76,21 +82,183 @@ ALTER TABLE sources ADD COLUMN IF NOT EXISTS recheck_count INT DEFAULT 0;
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS last_error_type TEXT;
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS last_error_message TEXT;
 ALTER TABLE sources ADD COLUMN IF NOT EXISTS last_error_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS next_scrape_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS scrape_interval_minutes INT;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS interval_override_minutes INT;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS error_streak INT DEFAULT 0;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS empty_streak INT DEFAULT 0;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS last_new_jobs INT DEFAULT 0;
 
-ALTER TABLE jobs ADD COLUMN IF NOT EXISTS applied_at TIMESTAMP WITH TIME ZONE;
 ALTER TABLE jobs ADD COLUMN IF NOT EXISTS source_type TEXT;
-ALTER TABLE jobs ADD COLUMN IF NOT EXISTS rejected BOOLEAN DEFAULT FALSE;
-ALTER TABLE jobs ADD COLUMN IF NOT EXISTS rejected_at TIMESTAMP WITH TIME ZONE;
 ALTER TABLE jobs ADD COLUMN IF NOT EXISTS closed BOOLEAN DEFAULT FALSE;
 ALTER TABLE jobs ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS content_hash TEXT;
+
+CREATE TABLE IF NOT EXISTS filter_rules (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    expression TEXT NOT NULL,
+    action TEXT NOT NULL, -- 'accept', 'reject', 'boost'
+    boost INT NOT NULL DEFAULT 0,
+    position INT NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS seniority TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS experience_years INT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS role_family TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS facts JSONB;
+
+-- Scores and triage state are per profile; jobs only hold the posting itself.
+-- The legacy jobs.match_score, match_summary, applied and rejected columns are
+-- copied into the default profile and dropped on startup (MigrateLegacyJobState).
+CREATE TABLE IF NOT EXISTS profile_jobs (
+    profile_id INT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    job_id INT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    match_score INT DEFAULT 0,
+    match_summary TEXT,
+    profile_version INT DEFAULT 0,
+    applied BOOLEAN DEFAULT FALSE,
+    applied_at TIMESTAMP WITH TIME ZONE,
+    rejected BOOLEAN DEFAULT FALSE,
+    rejected_at TIMESTAMP WITH TIME ZONE,
+    closed BOOLEAN DEFAULT FALSE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    keyword_breakdown JSONB,
+    -- AI model behind match_score (NULL when only keywords scored it) and when it was computed.
+    scored_model TEXT,
+    scored_at TIMESTAMP WITH TIME ZONE,
+    -- How match_score was computed (rule and AI scores, weights, strengths, weaknesses).
+    score_breakdown JSONB,
+    -- FALSE while a filter rule rejects the job for the profile: the row only remembers
+    -- the decision and score so unchanged postings are not sent to the AI again.
+    matched BOOLEAN NOT NULL DEFAULT TRUE,
+    -- The filter rule decision for this profile; a rule that rejects before scoring
+    -- leaves the score columns empty.
+    rule_decision JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (profile_id, job_id)
+);
+
+CREATE TABLE IF NOT EXISTS runs (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL, -- 'ingestion', 'source_scrape', 'discovery', 'rescore'
+    source_id INT REFERENCES sources(id) ON DELETE CASCADE,
+    trigger TEXT NOT NULL DEFAULT 'manual', -- 'manual', 'schedule'
+    status TEXT NOT NULL DEFAULT 'queued', -- 'queued', 'running', 'succeeded', 'failed', 'cancelled'
+    processed INT NOT NULL DEFAULT 0,
+    skipped INT NOT NULL DEFAULT 0,
+    jobs_saved INT NOT NULL DEFAULT 0,
+    error_count INT NOT NULL DEFAULT 0,
+    errors JSONB,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one active run per kind and source; concurrent triggers coalesce onto it.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_runs_active
+    ON runs(kind, COALESCE(source_id, 0))
+    WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_runs_created_at ON runs(created_at DESC);
+
+-- Durable work queue. Workers claim ready rows with FOR UPDATE SKIP LOCKED and
+-- hold them until locked_until; a crashed worker's task becomes claimable again.
+CREATE TABLE IF NOT EXISTS tasks (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL, -- 'scrape_source', 'analyze_candidate', 'score_job', 'verify_job'
+    dedupe_key TEXT NOT NULL DEFAULT '',
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'running', 'done', 'dead'
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    locked_by TEXT,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_active_key ON tasks(type, dedupe_key) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_tasks_ready ON tasks(type, run_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status, updated_at DESC);
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Circuit breakers for failing sources (key = source id) and hosts (key = host).
+CREATE TABLE IF NOT EXISTS circuits (
+    scope TEXT NOT NULL, -- 'source', 'host'
+    key TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'closed', -- 'closed', 'open', 'half_open'
+    failures INT NOT NULL DEFAULT 0,
+    trips INT NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP WITH TIME ZONE,
+    retry_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    last_failure_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (scope, key)
+);
+
+-- Bookkeeping for the scheduler's named jobs. Every replica upserts the spec on
+-- start; only the lease holder runs a job and writes its run fields.
+CREATE TABLE IF NOT EXISTS schedules (
+    name TEXT PRIMARY KEY,
+    spec TEXT NOT NULL,
+    jitter_seconds INT NOT NULL DEFAULT 0,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    running BOOLEAN NOT NULL DEFAULT FALSE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_duration_ms BIGINT,
+    last_status TEXT, -- 'succeeded', 'failed', 'cancelled'
+    last_error TEXT,
+    last_error_at TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    runs INT NOT NULL DEFAULT 0,
+    failures INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Cached AI answers. input_hash covers everything sent to the model, including
+-- the candidate profile, so profile edits never reuse stale answers.
+CREATE TABLE IF NOT EXISTS ai_cache (
+    operation TEXT NOT NULL, -- 'classify', 'match', 'extract'
+    model TEXT NOT NULL,
+    prompt_version TEXT NOT NULL,
+    input_hash TEXT NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (operation, model, prompt_version, input_hash)
+);
+
+-- AI requests per UTC day, model and operation, with tokens and estimated cost.
+CREATE TABLE IF NOT EXISTS ai_usage (
+    day DATE NOT NULL,
+    model TEXT NOT NULL,
+    operation TEXT NOT NULL, -- 'classify', 'match', 'extract'
+    requests INT NOT NULL DEFAULT 0,
+    failures INT NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, model, operation)
+);
 
-CREATE INDEX IF NOT EXISTS idx_jobs_match_score ON jobs(match_score);
 CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs(created_at);
-CREATE INDEX IF NOT EXISTS idx_jobs_applied_at ON jobs(applied_at);
-CREATE INDEX IF NOT EXISTS idx_jobs_rejected ON jobs(rejected);
 CREATE INDEX IF NOT EXISTS idx_jobs_closed ON jobs(closed);
+CREATE INDEX IF NOT EXISTS idx_jobs_classification ON jobs(role_family, seniority);
 CREATE INDEX IF NOT EXISTS idx_sources_normalized_url ON sources(normalized_url);
 CREATE INDEX IF NOT EXISTS idx_sources_host ON sources(host);
 CREATE INDEX IF NOT EXISTS idx_sources_page_type ON sources(page_type);
 CREATE INDEX IF NOT EXISTS idx_sources_alias ON sources(is_alias);
+CREATE INDEX IF NOT EXISTS idx_sources_next_scrape_at ON sources(next_scrape_at NULLS FIRST);
+CREATE INDEX IF NOT EXISTS idx_profile_jobs_job_id ON profile_jobs(job_id);
+CREATE INDEX IF NOT EXISTS idx_profile_jobs_ranking ON profile_jobs(profile_id, applied, match_score DESC);
 CREATE INDEX IF NOT EXISTS idx_stats_snapshots_created_at ON stats_snapshots(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ai_cache_expires_at ON ai_cache(expires_at);
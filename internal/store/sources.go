@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// scrapeEligibleSQL selects the sources ingestion scrapes: canonical job
+// listing pages that are not served through a known ATS.
+const scrapeEligibleSQL = `
+			is_job_site = TRUE
+			AND is_alias = FALSE
+			AND page_type IN ('career_root', 'job_list')
+			AND COALESCE(ats_backed, FALSE) = FALSE`
+
+const sourceColumns = `
+			id, 
+			url, 
+			COALESCE(normalized_url, ''),
+			COALESCE(host, ''),
+			type, 
+			COALESCE(page_type, ''),
+			is_alias,
+			COALESCE(canonical_url, ''),
+			is_job_site, 
+			tech_related, 
+			confidence, 
+			last_checked_at, 
+			last_scraped_at, 
+			discovered_at, 
+			COALESCE(classification_reason, ''),
+			COALESCE(classification_model, ''),
+			COALESCE(ats_backed, FALSE),
+			COALESCE(recheck_count, 0),
+			COALESCE(last_error_type, ''),
+			COALESCE(last_error_message, ''),
+			last_error_at,
+			next_scrape_at,
+			COALESCE(scrape_interval_minutes, 0),
+			COALESCE(interval_override_minutes, 0),
+			COALESCE(error_streak, 0),
+			COALESCE(empty_streak, 0),
+			COALESCE(last_new_jobs, 0)`
+
+func scanSource(row rowScanner) (*Source, error) {
+	var (
+		src          Source
+		lastChecked  sql.NullTime
+		lastScraped  sql.NullTime
+		discoveredAt sql.NullTime
+		lastErrorAt  sql.NullTime
+		nextScrape   sql.NullTime
+	)
+
+	if err := row.Scan(
+		&src.ID,
+		&src.URL,
+		&src.NormalizedURL,
+		&src.Host,
+		&src.Type,
+		&src.PageType,
+		&src.IsAlias,
+		&src.CanonicalURL,
+		&src.IsJobSite,
+		&src.TechRelated,
+		&src.Confidence,
+		&lastChecked,
+		&lastScraped,
+		&discoveredAt,
+		&src.Classification,
+		&src.ClassificationModel,
+		&src.ATSBacked,
+		&src.RecheckCount,
+		&src.LastErrorType,
+		&src.LastErrorMsg,
+		&lastErrorAt,
+		&nextScrape,
+		&src.ScrapeIntervalMinutes,
+		&src.IntervalOverrideMinutes,
+		&src.ErrorStreak,
+		&src.EmptyStreak,
+		&src.LastNewJobs,
+	); err != nil {
+		return nil, err
+	}
+
+	src.LastCheckedAt = scanNullTime(lastChecked)
+	src.LastScrapedAt = scanNullTime(lastScraped)
+	src.DiscoveredAt = scanNullTime(discoveredAt)
+	src.LastErrorAt = scanNullTime(lastErrorAt)
+	src.NextScrapeAt = scanNullTime(nextScrape)
+	return &src, nil
+}
+
+// GetSource returns a source by id, or nil when it does not exist.
+func (s *Store) GetSource(ctx context.Context, sourceID int) (*Source, error) {
+	src, err := scanSource(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+sourceColumns+`
+		FROM
+			sources
+		WHERE
+			id = $1`,
+		sourceID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []Source{*src}
+	if err := s.attachCircuits(ctx, sources); err != nil {
+		return nil, err
+	}
+	return &sources[0], nil
+}
+
+// ListScrapeSources returns a page of eligible sources ordered by id. Pass the
+// last id of the previous page as afterID to walk all of them without offset
+// pagination; dueOnly restricts the walk to sources whose next_scrape_at passed.
+func (s *Store) ListScrapeSources(ctx context.Context, afterID, limit int, dueOnly bool) ([]Source, error) {
+	limit, _ = normalizePagination(limit, 0)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+sourceColumns+`
+		FROM
+			sources
+		WHERE `+scrapeEligibleSQL+`
+			AND ($3 = FALSE OR next_scrape_at IS NULL OR next_scrape_at <= NOW())
+			AND id > $1
+		ORDER BY
+			id
+		LIMIT
+			$2`,
+		afterID,
+		limit,
+		dueOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []Source
+	for rows.Next() {
+		src, err := scanSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, *src)
+	}
+	return sources, rows.Err()
+}
+
+// SourceSchedule is the scheduling state written after each scrape attempt.
+type SourceSchedule struct {
+	IntervalMinutes int
+	NextScrapeAt    time.Time
+	ErrorStreak     int
+	EmptyStreak     int
+	LastNewJobs     int
+}
+
+func (s *Store) UpdateSourceSchedule(ctx context.Context, sourceID int, schedule SourceSchedule) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			sources
+		SET
+			scrape_interval_minutes = $1,
+			next_scrape_at = $2,
+			error_streak = $3,
+			empty_streak = $4,
+			last_new_jobs = $5
+		WHERE
+			id = $6`,
+		schedule.IntervalMinutes,
+		schedule.NextScrapeAt,
+		schedule.ErrorStreak,
+		schedule.EmptyStreak,
+		schedule.LastNewJobs,
+		sourceID,
+	)
+	return err
+}
+
+// DeferSourceScrape pushes a source's next scrape out to at least until.
+func (s *Store) DeferSourceScrape(ctx context.Context, sourceID int, until time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			sources
+		SET
+			next_scrape_at = GREATEST(COALESCE(next_scrape_at, $2), $2)
+		WHERE
+			id = $1`,
+		sourceID,
+		until,
+	)
+	return err
+}
+
+// SetSourceIntervalOverride pins a source to a fixed scrape interval; 0 goes
+// back to the adaptive interval. scrapeNow makes the source due immediately.
+func (s *Store) SetSourceIntervalOverride(ctx context.Context, sourceID, minutes int, scrapeNow bool) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			sources
+		SET
+			interval_override_minutes = NULLIF($1, 0),
+			next_scrape_at = CASE WHEN $2 THEN NOW() ELSE next_scrape_at END
+		WHERE
+			id = $3`,
+		minutes,
+		scrapeNow,
+		sourceID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,475 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	TaskScrapeSource     = "scrape_source"
+	TaskAnalyzeCandidate = "analyze_candidate"
+	TaskScoreJob         = "score_job"
+	TaskVerifyJob        = "verify_job"
+
+	TaskPending = "pending"
+	TaskRunning = "running"
+	TaskDone    = "done"
+	TaskDead    = "dead"
+)
+
+// TaskTypes lists every task type the queue knows.
+var TaskTypes = []string{TaskScrapeSource, TaskAnalyzeCandidate, TaskScoreJob, TaskVerifyJob}
+
+const defaultTaskMaxAttempts = 5
+
+// ErrTaskActive is returned when requeueing a dead task whose dedupe key is
+// already held by a pending or running task.
+var ErrTaskActive = errors.New("an active task with the same key exists")
+
+// ErrTaskLost is returned when finishing a task the worker no longer holds:
+// its visibility timeout ran out and it was claimed again or dead-lettered.
+var ErrTaskLost = errors.New("task is no longer held by this claim")
+
+// Task is one unit of queued work. Payload is type specific JSON.
+type Task struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Key         string          `json:"key,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       *time.Time      `json:"run_at,omitempty"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   *time.Time      `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time      `json:"updated_at,omitempty"`
+}
+
+// NewTask describes a task to enqueue. Key deduplicates: while a task with the
+// same type and key is pending or running, enqueueing returns that task.
+type NewTask struct {
+	Type        string
+	Key         string
+	Payload     any
+	MaxAttempts int
+	RunAt       time.Time
+}
+
+// TaskClaim selects the tasks a worker takes. Claimed tasks stay invisible to
+// other workers for Visibility; if the worker does not finish them by then
+// they are handed out again.
+type TaskClaim struct {
+	Types      []string
+	Key        string
+	Worker     string
+	Limit      int
+	Visibility time.Duration
+}
+
+const taskColumns = `
+	id,
+	type,
+	dedupe_key,
+	payload,
+	status,
+	attempts,
+	max_attempts,
+	run_at,
+	locked_until,
+	COALESCE(locked_by, ''),
+	COALESCE(last_error, ''),
+	created_at,
+	updated_at`
+
+func scanTask(row rowScanner) (*Task, error) {
+	var (
+		t           Task
+		payload     []byte
+		runAt       sql.NullTime
+		lockedUntil sql.NullTime
+		createdAt   sql.NullTime
+		updatedAt   sql.NullTime
+	)
+	if err := row.Scan(
+		&t.ID,
+		&t.Type,
+		&t.Key,
+		&payload,
+		&t.Status,
+		&t.Attempts,
+		&t.MaxAttempts,
+		&runAt,
+		&lockedUntil,
+		&t.LockedBy,
+		&t.LastError,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	t.Payload = json.RawMessage(payload)
+	t.RunAt = scanNullTime(runAt)
+	t.LockedUntil = scanNullTime(lockedUntil)
+	t.CreatedAt = scanNullTime(createdAt)
+	t.UpdatedAt = scanNullTime(updatedAt)
+	return &t, nil
+}
+
+func scanTasks(rows *sql.Rows) ([]Task, error) {
+	defer rows.Close()
+	var tasks []Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *t)
+	}
+	return tasks, rows.Err()
+}
+
+// EnqueueTask adds a task, or returns the pending/running task with the same
+// type and key with created=false.
+func (s *Store) EnqueueTask(ctx context.Context, nt NewTask) (*Task, bool, error) {
+	payload, err := json.Marshal(nt.Payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("encode task payload: %w", err)
+	}
+	maxAttempts := nt.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultTaskMaxAttempts
+	}
+	runAt := nt.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	task, err := scanTask(s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			tasks (type, dedupe_key, payload, max_attempts, run_at, created_at, updated_at)
+		VALUES
+			($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (type, dedupe_key) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING `+taskColumns,
+		nt.Type,
+		nt.Key,
+		payload,
+		maxAttempts,
+		runAt,
+	))
+	if err == nil {
+		return task, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	task, err = scanTask(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+taskColumns+`
+		FROM
+			tasks
+		WHERE
+			type = $1
+			AND dedupe_key = $2
+			AND status IN ('pending', 'running')`,
+		nt.Type,
+		nt.Key,
+	))
+	if err == sql.ErrNoRows {
+		// The active task finished between the insert and the lookup; try once more.
+		return s.EnqueueTask(ctx, nt)
+	}
+	return task, false, err
+}
+
+// ClaimTasks locks up to claim.Limit ready tasks for one worker. Tasks whose
+// visibility timeout expired after their last attempt are dead-lettered
+// instead of being handed out again.
+func (s *Store) ClaimTasks(ctx context.Context, claim TaskClaim) ([]Task, error) {
+	types := pq.Array(nonNilStrings(claim.Types))
+	if _, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			tasks
+		SET
+			status = 'dead',
+			last_error = 'visibility timeout expired on final attempt',
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE
+			type = ANY($1)
+			AND status = 'running'
+			AND locked_until < NOW()
+			AND attempts >= max_attempts`,
+		types,
+	); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`UPDATE
+			tasks
+		SET
+			status = 'running',
+			attempts = attempts + 1,
+			locked_until = NOW() + make_interval(secs => $3),
+			locked_by = $4,
+			updated_at = NOW()
+		WHERE
+			id IN (
+				SELECT
+					id
+				FROM
+					tasks
+				WHERE
+					type = ANY($1)
+					AND ($5 = '' OR dedupe_key = $5)
+					AND run_at <= NOW()
+					AND (
+						status = 'pending'
+						OR (status = 'running' AND locked_until < NOW())
+					)
+				ORDER BY
+					run_at,
+					id
+				LIMIT
+					$2
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING `+taskColumns,
+		types,
+		max(claim.Limit, 1),
+		claim.Visibility.Seconds(),
+		claim.Worker,
+		claim.Key,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// CompleteTask marks a claimed task done. The update only applies while the
+// claim still holds the task; otherwise ErrTaskLost is returned.
+func (s *Store) CompleteTask(ctx context.Context, task Task) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			tasks
+		SET
+			status = 'done',
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE
+			id = $1
+			AND status = 'running'
+			AND locked_by = $2
+			AND attempts = $3`,
+		task.ID,
+		task.LockedBy,
+		task.Attempts,
+	)
+	return claimApplied(res, err)
+}
+
+// FailTask records a failed attempt. The task runs again at retryAt, or is
+// dead-lettered once it used all its attempts; dead reports which happened.
+// ErrTaskLost is returned when the claim no longer holds the task.
+func (s *Store) FailTask(ctx context.Context, task Task, message string, retryAt time.Time) (dead bool, err error) {
+	var status string
+	err = s.db.QueryRowContext(
+		ctx,
+		`UPDATE
+			tasks
+		SET
+			status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+			last_error = $4,
+			run_at = $5,
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE
+			id = $1
+			AND status = 'running'
+			AND locked_by = $2
+			AND attempts = $3
+		RETURNING
+			status`,
+		task.ID,
+		task.LockedBy,
+		task.Attempts,
+		message,
+		retryAt,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return false, ErrTaskLost
+	}
+	return status == TaskDead, err
+}
+
+// ReleaseTask hands a claimed task back without counting the attempt, e.g.
+// when the worker shuts down before starting it. ErrTaskLost is returned when
+// the claim no longer holds the task.
+func (s *Store) ReleaseTask(ctx context.Context, task Task) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE
+			tasks
+		SET
+			status = 'pending',
+			attempts = GREATEST(attempts - 1, 0),
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE
+			id = $1
+			AND status = 'running'
+			AND locked_by = $2
+			AND attempts = $3`,
+		task.ID,
+		task.LockedBy,
+		task.Attempts,
+	)
+	return claimApplied(res, err)
+}
+
+// claimApplied turns an update guarded by a task claim into ErrTaskLost when
+// it matched no row.
+func claimApplied(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTaskLost
+	}
+	return nil
+}
+
+// ListTasks returns tasks newest first, optionally filtered by status and type.
+func (s *Store) ListTasks(ctx context.Context, status, taskType string, limit, offset int) ([]Task, int, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	var total int
+	if err := s.db.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM
+			tasks
+		WHERE
+			($1 = '' OR status = $1)
+			AND ($2 = '' OR type = $2)`,
+		status,
+		taskType,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+taskColumns+`
+		FROM
+			tasks
+		WHERE
+			($1 = '' OR status = $1)
+			AND ($2 = '' OR type = $2)
+		ORDER BY
+			updated_at DESC,
+			id DESC
+		LIMIT
+			$3
+		OFFSET
+			$4`,
+		status,
+		taskType,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	tasks, err := scanTasks(rows)
+	return tasks, total, err
+}
+
+// RequeueTask resets a dead task so it runs again with a fresh attempt budget.
+func (s *Store) RequeueTask(ctx context.Context, id int64) (*Task, error) {
+	task, err := scanTask(s.db.QueryRowContext(
+		ctx,
+		`UPDATE
+			tasks
+		SET
+			status = 'pending',
+			attempts = 0,
+			run_at = NOW(),
+			locked_until = NULL,
+			locked_by = NULL,
+			updated_at = NOW()
+		WHERE
+			id = $1
+			AND status = 'dead'
+		RETURNING `+taskColumns,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrTaskActive
+	}
+	return task, err
+}
+
+// CountReadyTasks counts the tasks of a type that ClaimTasks would hand out
+// now: pending ones that are due and running ones whose visibility expired.
+func (s *Store) CountReadyTasks(ctx context.Context, taskType string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM
+			tasks
+		WHERE
+			type = $1
+			AND run_at <= NOW()
+			AND (
+				status = 'pending'
+				OR (status = 'running' AND locked_until < NOW())
+			)`,
+		taskType,
+	).Scan(&n)
+	return n, err
+}
+
+// DeleteFinishedTasks removes completed tasks older than olderThan. Dead
+// tasks are kept until they are requeued or cleaned up by hand.
+func (s *Store) DeleteFinishedTasks(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM
+			tasks
+		WHERE
+			status = 'done'
+			AND updated_at < $1`,
+		time.Now().Add(-olderThan),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -7,6 +7,7 @@ const jobModal = document.getElementById('jobModal');
 const applyModal = document.getElementById('applyModal');
 const statsGrid = document.getElementById('statsGrid');
 const statsModal = document.getElementById('statsModal');
+const profileSelect = document.getElementById('profileSelect');
 
 const state = {
     jobs: [],
@@ -19,6 +20,8 @@ const state = {
     jobsTotal: 0,
     sourcesTotal: 0,
     statusFilter: 'active', // active, applied, rejected, closed, all
+    profile: localStorage.getItem('profile') || 'default',
+    profiles: [],
     activeTotal: 0,
     stats: null,
     statsHistoryMetric: '',
@@ -170,6 +173,14 @@ function renderJobs(jobs) {
     updateActiveCount();
 }
 
+// renderCircuit flags a tripped circuit breaker on a source row.
+function renderCircuit(scope, circuit) {
+    if (!circuit || circuit.state === 'closed') return '';
+    const until = circuit.retry_at ? ` until ${new Date(circuit.retry_at).toLocaleString()}` : '';
+    const title = escapeHTML(circuit.last_error || '');
+    return `<span class="chip" style="margin-left:6px;" title="${title}">${scope} circuit ${escapeHTML(circuit.state.replace('_', '-'))}${escapeHTML(until)} (${circuit.failures} failures)</span>`;
+}
+
 function renderSources(items) {
     state.sources = items || [];
     if (!sourcesList) return;
@@ -186,6 +197,8 @@ function renderSources(items) {
                     <span class="chip">${escapeHTML(src.type || src.source_type || 'unknown')}</span>
                     ${src.confidence ? `<span style="margin-left:6px;">${Math.round(src.confidence * 100)}% confidence</span>` : '<span style="margin-left:6px;">pending</span>'}
                     ${src.reason ? `<span style="margin-left:6px;">${escapeHTML(src.reason)}</span>` : ''}
+                    ${renderCircuit('source', src.circuit)}
+                    ${renderCircuit('host', src.host_circuit)}
                 </div>
             </div>
             <span class="status ${src.status || 'accepted'}">${escapeHTML(src.status || 'accepted')}</span>
@@ -251,12 +264,39 @@ function renderStats(stats) {
     `).join('');
 }
 
+function profileParam() {
+    return `profile=${encodeURIComponent(state.profile)}`;
+}
+
+async function fetchProfiles() {
+    if (!profileSelect) return;
+    try {
+        const response = await fetch(`${API_URL}/profiles`);
+        const payload = await response.json();
+        state.profiles = (payload.items || []).filter((p) => p.active);
+        if (!state.profiles.some((p) => p.name === state.profile)) {
+            state.profile = 'default';
+        }
+        profileSelect.innerHTML = state.profiles.map((p) => `
+            <option value="${escapeHTML(p.name)}" ${p.name === state.profile ? 'selected' : ''}>${escapeHTML(p.name)}</option>
+        `).join('');
+    } catch (error) {
+        console.error('Failed to load profiles', error);
+    }
+}
+
+function setProfile(name) {
+    state.profile = name || 'default';
+    localStorage.setItem('profile', state.profile);
+    fetchJobs(0);
+}
+
 async function fetchJobs(page = 0) {
     state.jobsPage = page;
     if (!jobList) return;
     jobList.innerHTML = '<div class="loading">Loading jobs...</div>';
     try {
-        const response = await fetch(`${API_URL}/jobs?limit=${state.pageSize}&offset=${page * state.pageSize}`);
+        const response = await fetch(`${API_URL}/jobs?${profileParam()}&limit=${state.pageSize}&offset=${page * state.pageSize}`);
         const payload = await response.json();
         const items = payload.items || payload || [];
         state.jobsTotal = payload.total || items.length;
@@ -506,12 +546,51 @@ function showJobDetail(id) {
             <div class="job-source" style="margin-top: 8px;">Source: <a href="${job.source_url || job.url}" target="_blank" rel="noopener">${escapeHTML(source)}</a></div>
             <div class="modal-body">${descriptionHtml}</div>
             <div class="summary" style="margin-top: 12px;">Summary: ${escapeHTML(summary)}</div>
+            <div id="score-breakdown" class="score-breakdown summary">Loading score breakdown...</div>
             <div class="modal-actions">
                 <button ${applied ? 'disabled' : ''} onclick='applyToJob(${job.id}, ${JSON.stringify(job.url)}, event)'>${applied ? 'Applied' : 'Apply'}</button>
             </div>
         </div>
     `;
     jobModal.classList.remove('hidden');
+    fetchScoreBreakdown(job.id);
+}
+
+async function fetchScoreBreakdown(id) {
+    const box = document.getElementById('score-breakdown');
+    try {
+        const response = await fetch(`${API_URL}/jobs/${id}/score?${profileParam()}`);
+        if (response.status === 404) {
+            box.textContent = 'No score breakdown yet; it is recorded the next time this job is scored.';
+            return;
+        }
+        if (!response.ok) throw new Error(`HTTP ${response.status}`);
+        box.innerHTML = renderScoreBreakdown(await response.json());
+    } catch (error) {
+        box.textContent = `Failed to load score breakdown: ${error.message}`;
+    }
+}
+
+function renderScoreBreakdown(b) {
+    const weights = b.ai_weight
+        ? `${Math.round(b.rule_weight * 100)}% rules / ${Math.round(b.ai_weight * 100)}% AI`
+        : 'rules only';
+    const ai = b.ai_score !== undefined && b.ai_score !== null
+        ? `${b.ai_score}`
+        : (b.ai_failed ? 'failed' : 'not asked');
+    const terms = (b.matched_terms || [])
+        .map((t) => `<span class="tag">${escapeHTML(t.term)} ${t.points > 0 ? '+' : ''}${Number(t.points).toFixed(1)}</span>`)
+        .join(' ');
+    const list = (items) => (items || []).map((item) => `<li>${escapeHTML(item)}</li>`).join('');
+    const model = b.model ? `${escapeHTML(b.model)} (${escapeHTML(b.prompt_version || '')})` : 'none';
+
+    return `
+        <div class="score-breakdown-head">Score ${b.score}: rule score ${b.rule_score}, AI score ${ai}, ${weights}${b.boost ? `, rule boost ${b.boost > 0 ? '+' : ''}${b.boost}` : ''}</div>
+        ${terms ? `<div class="score-breakdown-terms">${terms}</div>` : ''}
+        ${b.strengths && b.strengths.length ? `<div>Strengths<ul>${list(b.strengths)}</ul></div>` : ''}
+        ${b.weaknesses && b.weaknesses.length ? `<div>Weaknesses<ul>${list(b.weaknesses)}</ul></div>` : ''}
+        <div>Model: ${model} • scored ${formatDateTime(b.scored_at)}</div>
+    `;
 }
 
 function closeJobModal(event) {
@@ -633,7 +712,7 @@ async function confirmApplied(event) {
     }
 
     try {
-        const response = await fetch(`${API_URL}/jobs/${pending.id}/apply`, { method: 'POST' });
+        const response = await fetch(`${API_URL}/jobs/${pending.id}/apply?${profileParam()}`, { method: 'POST' });
         if (!response.ok) {
             throw new Error('Failed to mark as applied');
         }
@@ -654,7 +733,7 @@ async function rejectJob(event) {
         return;
     }
     try {
-        const response = await fetch(`${API_URL}/jobs/${pending.id}/reject`, { method: 'POST' });
+        const response = await fetch(`${API_URL}/jobs/${pending.id}/reject?${profileParam()}`, { method: 'POST' });
         if (!response.ok) {
             throw new Error('Failed to mark as not a match');
         }
@@ -675,7 +754,7 @@ async function closeJob(event) {
         return;
     }
     try {
-        const response = await fetch(`${API_URL}/jobs/${pending.id}/close`, { method: 'POST' });
+        const response = await fetch(`${API_URL}/jobs/${pending.id}/close?${profileParam()}`, { method: 'POST' });
         if (!response.ok) {
             throw new Error('Failed to mark as closed');
         }
@@ -689,4 +768,5 @@ async function closeJob(event) {
 }
 
 // initial load
+fetchProfiles();
 setTab('jobs');